@@ -0,0 +1,129 @@
+// Package callback receives the status callbacks twilio sends for calls,
+// messages, recordings and transcriptions, and dispatches them as typed events
+// to Go functions.
+package callback
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tmc/twilio"
+	"github.com/tmc/twilio/optout"
+	"github.com/tmc/twilio/twiml"
+)
+
+// IdempotencyHeader carries a token twilio keeps identical across retried
+// deliveries of the same callback.
+const IdempotencyHeader = "I-Twilio-Idempotency-Token"
+
+// DefaultDedupTTL is how long NewHandler remembers delivered callbacks.
+const DefaultDedupTTL = 24 * time.Hour
+
+// Handler is an http.Handler for status callbacks. The kind of callback is
+// determined from its parameters and the matching function is called; kinds
+// without a function are acknowledged and dropped.
+type Handler struct {
+	// Validator verifies the signature of each callback.
+	twilio.Validator
+	// Deduper drops retried deliveries. All deliveries are dispatched if nil.
+	Deduper Deduper
+	// OptOuts, if set, records the recipients of messages that failed
+	// because the recipient opted out.
+	OptOuts optout.Store
+
+	CallStatus      func(*twiml.VoiceRequest)
+	MessageStatus   func(*twiml.MessageStatusRequest)
+	RecordingStatus func(*twiml.RecordingStatusRequest)
+	Transcription   func(*twiml.TranscriptionRequest)
+}
+
+// NewHandler creates a handler verifying signatures with authToken and
+// de-duplicating deliveries in memory for DefaultDedupTTL.
+func NewHandler(authToken string) *Handler {
+	return &Handler{
+		Validator: twilio.Validator{AuthToken: authToken},
+		Deduper:   NewMemoryDeduper(DefaultDedupTTL),
+	}
+}
+
+// ServeHTTP parses and dispatches a status callback.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.Verify(w, r) {
+		return
+	}
+	dispatch, key, err := h.parse(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if token := r.Header.Get(IdempotencyHeader); token != "" {
+		key = token
+	}
+	if h.Deduper == nil || !h.Deduper.Seen(key) {
+		dispatch()
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parse determines the kind of callback, returning a function dispatching
+// the parsed event and a key identifying the delivery
+func (h *Handler) parse(r *http.Request) (dispatch func(), key string, err error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, "", err
+	}
+
+	switch {
+	default:
+		return nil, "", fmt.Errorf("unknown status callback")
+	case r.Form.Get(twiml.TwiTranscriptionSid) != "":
+		tr, err := twiml.ParseTranscriptionRequest(r)
+		if err != nil {
+			return nil, "", err
+		}
+		return func() {
+			if h.Transcription != nil {
+				h.Transcription(tr)
+			}
+		}, tr.TranscriptionSid + ":" + tr.TranscriptionStatus, nil
+	case r.Form.Get(twiml.TwiRecordingStatus) != "":
+		rr, err := twiml.ParseRecordingStatusRequest(r)
+		if err != nil {
+			return nil, "", err
+		}
+		return func() {
+			if h.RecordingStatus != nil {
+				h.RecordingStatus(rr)
+			}
+		}, rr.RecordingSid + ":" + string(rr.RecordingStatus), nil
+	case r.Form.Get(twiml.TwiMessageStatus) != "",
+		r.Form.Get(twiml.TwiSmsStatus) != "":
+		mr, err := twiml.ParseMessageStatusRequest(r)
+		if err != nil {
+			return nil, "", err
+		}
+		if h.OptOuts != nil && mr.ErrorCode == optout.ErrorCode {
+			if err := h.OptOuts.OptOut(mr.To); err != nil {
+				return nil, "", err
+			}
+		}
+		return func() {
+			if h.MessageStatus != nil {
+				h.MessageStatus(mr)
+			}
+		}, mr.MessageSid + ":" + string(mr.MessageStatus), nil
+	case r.Form.Get(twiml.TwiCallStatus) != "":
+		vr, err := twiml.ParseVoiceRequest(r)
+		if err != nil {
+			return nil, "", err
+		}
+		key := vr.CallSid + ":" + string(vr.CallStatus) + ":" +
+			strconv.Itoa(vr.SequenceNumber)
+		return func() {
+			if h.CallStatus != nil {
+				h.CallStatus(vr)
+			}
+		}, key, nil
+	}
+}
@@ -0,0 +1,173 @@
+package callback_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/tmc/twilio"
+	"github.com/tmc/twilio/callback"
+	"github.com/tmc/twilio/twiml"
+)
+
+func post(h http.Handler, authToken string, form url.Values) int {
+	return postToken(h, authToken, "", form)
+}
+
+// postToken posts form with token in the idempotency header, if not empty
+func postToken(h http.Handler, authToken, token string, form url.Values) int {
+	r := httptest.NewRequest("POST", "https://example.com/status", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set(twilio.SignatureHeader,
+		twilio.Signature(authToken, "https://example.com/status", form))
+	if token != "" {
+		r.Header.Set(callback.IdempotencyHeader, token)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w.Code
+}
+
+func TestMessageStatusDedup(t *testing.T) {
+	h := callback.NewHandler("secret")
+	h.BaseURL = "https://example.com"
+
+	var got []*twiml.MessageStatusRequest
+	h.MessageStatus = func(m *twiml.MessageStatusRequest) { got = append(got, m) }
+
+	form := url.Values{
+		"MessageSid":    {"SM123"},
+		"AccountSid":    {"AC123"},
+		"MessageStatus": {"undelivered"},
+		"ErrorCode":     {"30003"},
+	}
+	for i := 0; i < 2; i++ {
+		if code := post(h, "secret", form); code != http.StatusNoContent {
+			t.Fatalf("delivery %d: status %d", i, code)
+		}
+	}
+	if len(got) != 1 {
+		t.Fatalf("dispatched %d events, want 1", len(got))
+	}
+	if got[0].MessageStatus != twiml.TwiUndelivered || got[0].ErrorCode != 30003 {
+		t.Errorf("unexpected event: %+v", got[0])
+	}
+}
+
+func TestInvalidSignature(t *testing.T) {
+	h := callback.NewHandler("secret")
+	h.BaseURL = "https://example.com"
+	h.CallStatus = func(*twiml.VoiceRequest) { t.Error("dispatched unsigned callback") }
+
+	form := url.Values{
+		"CallSid":    {"CA123"},
+		"AccountSid": {"AC123"},
+		"CallStatus": {"completed"},
+	}
+	if code := post(h, "wrong", form); code != http.StatusForbidden {
+		t.Errorf("status %d, want %d", code, http.StatusForbidden)
+	}
+}
+
+type delivery struct {
+	token string
+	form  url.Values
+}
+
+var (
+	recording = url.Values{
+		"AccountSid":      {"AC123"},
+		"CallSid":         {"CA123"},
+		"RecordingSid":    {"RE123"},
+		"RecordingStatus": {"completed"},
+	}
+	recordingFailed = url.Values{
+		"AccountSid":      {"AC123"},
+		"RecordingSid":    {"RE123"},
+		"RecordingStatus": {"failed"},
+	}
+	transcription = url.Values{
+		"AccountSid":          {"AC123"},
+		"TranscriptionSid":    {"TR123"},
+		"TranscriptionStatus": {"completed"},
+		"TranscriptionText":   {"hello"},
+		"RecordingSid":        {"RE123"},
+	}
+	transcriptionFailed = url.Values{
+		"AccountSid":          {"AC123"},
+		"TranscriptionSid":    {"TR123"},
+		"TranscriptionStatus": {"failed"},
+	}
+	call = url.Values{
+		"AccountSid":     {"AC123"},
+		"CallSid":        {"CA123"},
+		"CallStatus":     {"ringing"},
+		"SequenceNumber": {"0"},
+	}
+	callAnswered = url.Values{
+		"AccountSid":     {"AC123"},
+		"CallSid":        {"CA123"},
+		"CallStatus":     {"in-progress"},
+		"SequenceNumber": {"1"},
+	}
+)
+
+func TestDispatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		deliveries []delivery
+		// dispatched events by kind
+		recordings, transcriptions, calls int
+	}{
+		{name: "recording", deliveries: []delivery{{form: recording}}, recordings: 1},
+		{name: "recording retried",
+			deliveries: []delivery{{form: recording}, {form: recording}}, recordings: 1},
+		{name: "recording statuses",
+			deliveries: []delivery{{form: recording}, {form: recordingFailed}}, recordings: 2},
+		{name: "transcription", deliveries: []delivery{{form: transcription}}, transcriptions: 1},
+		{name: "transcription retried",
+			deliveries: []delivery{{form: transcription}, {form: transcription}}, transcriptions: 1},
+		{name: "transcription statuses",
+			deliveries: []delivery{{form: transcription}, {form: transcriptionFailed}}, transcriptions: 2},
+		{name: "call statuses",
+			deliveries: []delivery{{form: call}, {form: callAnswered}, {form: call}}, calls: 2},
+		{name: "token retried",
+			deliveries: []delivery{{"tok1", recording}, {"tok1", recording}}, recordings: 1},
+		{name: "token overrides parameters",
+			deliveries: []delivery{{"tok1", recording}, {"tok1", recordingFailed}}, recordings: 1},
+		{name: "tokens differ",
+			deliveries: []delivery{{"tok1", transcription}, {"tok2", transcription}}, transcriptions: 2},
+		{name: "token shared across kinds",
+			deliveries: []delivery{{"tok1", call}, {"tok1", transcription}}, calls: 1},
+	}
+	for _, tt := range tests {
+		var recordings, transcriptions, calls int
+		h := callback.NewHandler("secret")
+		h.BaseURL = "https://example.com"
+		h.RecordingStatus = func(r *twiml.RecordingStatusRequest) {
+			if r.RecordingSid != "RE123" {
+				t.Errorf("%s: recording %+v", tt.name, r)
+			}
+			recordings++
+		}
+		h.Transcription = func(tr *twiml.TranscriptionRequest) {
+			if tr.TranscriptionSid != "TR123" {
+				t.Errorf("%s: transcription %+v", tt.name, tr)
+			}
+			transcriptions++
+		}
+		h.CallStatus = func(*twiml.VoiceRequest) { calls++ }
+
+		for i, d := range tt.deliveries {
+			if code := postToken(h, "secret", d.token, d.form); code != http.StatusNoContent {
+				t.Fatalf("%s: delivery %d: status %d", tt.name, i, code)
+			}
+		}
+		if recordings != tt.recordings || transcriptions != tt.transcriptions || calls != tt.calls {
+			t.Errorf("%s: dispatched %d recordings, %d transcriptions, %d calls",
+				tt.name, recordings, transcriptions, calls)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package callback
+
+import (
+	"sync"
+	"time"
+)
+
+// Deduper remembers delivered callbacks so that retried deliveries of the
+// same callback are dispatched only once.
+type Deduper interface {
+	// Seen records key and reports whether it had been recorded before.
+	Seen(key string) bool
+}
+
+// MemoryDeduper is an in-memory Deduper remembering keys for a limited time.
+type MemoryDeduper struct {
+	ttl time.Duration
+
+	mu     sync.Mutex
+	keys   map[string]time.Time
+	pruned time.Time
+}
+
+// NewMemoryDeduper creates a deduper remembering each key for ttl.
+func NewMemoryDeduper(ttl time.Duration) *MemoryDeduper {
+	return &MemoryDeduper{ttl: ttl, keys: make(map[string]time.Time)}
+}
+
+// Seen records key and reports whether it was recorded within the ttl.
+func (d *MemoryDeduper) Seen(key string) bool {
+	now := time.Now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// drop expired keys at most once per ttl
+	if now.Sub(d.pruned) > d.ttl {
+		for k, t := range d.keys {
+			if now.Sub(t) > d.ttl {
+				delete(d.keys, k)
+			}
+		}
+		d.pruned = now
+	}
+
+	if t, ok := d.keys[key]; ok && now.Sub(t) <= d.ttl {
+		return true
+	}
+	d.keys[key] = now
+	return false
+}
@@ -0,0 +1,418 @@
+// Package callsim simulates phone calls against TwiML http handlers, so voice
+// flows can be tested offline. A simulated call posts twilio shaped voice
+// webhooks to the handler, executes the returned TwiML and follows Redirect,
+// Gather, Record, Dial and Enqueue actions with scripted caller input, and
+// records what the caller would hear in a Transcript.
+package callsim
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmc/twilio"
+	"github.com/tmc/twilio/twiml"
+)
+
+// DefaultMaxRequests is the request limit of a simulator without MaxRequests.
+const DefaultMaxRequests = 100
+
+// inputVerb names transcript events of scripted caller input
+const inputVerb = "Input"
+
+// apiVersion is the twilio API version sent with simulated requests
+const apiVersion = "2010-04-01"
+
+// Input is scripted caller input, consumed in order by Gather and Record.
+type Input struct {
+	Digits     string
+	Speech     string
+	Confidence float64
+	// Duration is the length of a recording, 5 seconds if zero.
+	Duration time.Duration
+}
+
+// Digits returns input pressing digits on the keypad.
+func Digits(digits string) Input {
+	return Input{Digits: digits}
+}
+
+// Speech returns input saying text.
+func Speech(text string) Input {
+	return Input{Speech: text, Confidence: 0.9}
+}
+
+// NoInput is input where the caller stays silent, making a Gather time out.
+var NoInput = Input{}
+
+// Simulator places simulated calls to a voice application handler.
+type Simulator struct {
+	Handler http.Handler
+	// URL is the voice application url, e.g. "https://example.com/voice".
+	// Relative urls in TwiML are resolved against the current document.
+	URL string
+	// AuthToken signs each request with an X-Twilio-Signature if set.
+	AuthToken  string
+	AccountSid string
+	From       string
+	To         string
+	// Params are added to every voice request, e.g. FromCountry.
+	Params url.Values
+	// StatusCallback receives the completed call status callback if set.
+	StatusCallback string
+	// DialCallStatus is the outcome reported to Dial actions, "completed"
+	// if empty.
+	DialCallStatus string
+	// QueueResult is the outcome reported to Enqueue actions, "hangup" if
+	// empty.
+	QueueResult string
+	// MaxRequests bounds the requests of a call, catching flows that loop.
+	MaxRequests int
+}
+
+// call is the state of one simulated call
+type call struct {
+	sim      *Simulator
+	sid      string
+	inputs   []Input
+	start    time.Time
+	status   string
+	t        *Transcript
+	redirect *redirect // next document to request, nil ends the call
+}
+
+type redirect struct {
+	method string
+	url    string
+	params url.Values
+}
+
+// Call places a call and runs it until the caller hangs up, the handler
+// hangs up, or the TwiML runs out. inputs are consumed by Gather and Record
+// verbs; once they are used up the caller stays silent.
+func (s *Simulator) Call(inputs ...Input) (*Transcript, error) {
+	c := &call{
+		sim:    s,
+		sid:    newSid("CA"),
+		inputs: inputs,
+		start:  time.Now(),
+		status: twiml.TwiRinging,
+	}
+	c.t = &Transcript{CallSid: c.sid}
+	c.redirect = &redirect{method: "POST", url: s.URL}
+
+	max := s.MaxRequests
+	if max == 0 {
+		max = DefaultMaxRequests
+	}
+	for c.redirect != nil {
+		if c.t.Requests >= max {
+			return c.t, fmt.Errorf("call exceeded %d requests", max)
+		}
+		r := c.redirect
+		c.redirect = nil
+		doc, err := c.request(r.method, r.url, r.params)
+		if err != nil {
+			return c.t, err
+		}
+		c.status = twiml.TwiInProgress
+		if err := c.execute(r.url, doc); err != nil {
+			return c.t, err
+		}
+	}
+
+	c.status = twiml.TwiCompleted
+	if s.StatusCallback != "" {
+		params := url.Values{twiml.TwiCallDuration: {strconv.Itoa(
+			int(time.Since(c.start).Seconds()))}}
+		if _, err := c.post(s.StatusCallback, params); err != nil {
+			return c.t, err
+		}
+	}
+	return c.t, nil
+}
+
+// element is a TwiML element of any kind
+type element struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Text     string     `xml:",chardata"`
+	InnerXML string     `xml:",innerxml"`
+	Children []element  `xml:",any"`
+}
+
+func (e element) attr(name string) string {
+	for _, a := range e.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// spoken returns the text of a Say with SSML markup as the caller hears it
+func (e element) spoken() string {
+	resp, err := twiml.ParseString("<Response><Say>" + e.InnerXML + "</Say></Response>")
+	if err != nil {
+		return strings.TrimSpace(e.Text)
+	}
+	return resp.Response[0].(twiml.Say).PlainText()
+}
+
+// event records e as executed from the document at docURL
+func (c *call) event(e element, docURL string) {
+	ev := Event{
+		Verb:  e.XMLName.Local,
+		Text:  strings.TrimSpace(e.Text),
+		Attrs: map[string]string{},
+		URL:   docURL,
+	}
+	for _, a := range e.Attrs {
+		ev.Attrs[a.Name.Local] = a.Value
+	}
+	if ev.Verb == "Say" && len(e.Children) > 0 {
+		ev.Text = e.spoken()
+	}
+	if ev.Verb == "Dial" {
+		if ev.Text != "" {
+			ev.Targets = append(ev.Targets, "Number "+ev.Text)
+			ev.Text = ""
+		}
+		for _, n := range e.Children {
+			target := strings.TrimSpace(n.Text)
+			for _, c := range n.Children {
+				if c.XMLName.Local == "Identity" {
+					target = strings.TrimSpace(c.Text)
+				}
+			}
+			ev.Targets = append(ev.Targets, n.XMLName.Local+" "+target)
+		}
+	}
+	c.t.Events = append(c.t.Events, ev)
+}
+
+// execute runs the verbs of a TwiML document. It returns when the call ends
+// or continues with another document, set in c.redirect.
+func (c *call) execute(docURL string, doc *element) error {
+	for _, v := range doc.Children {
+		switch v.XMLName.Local {
+		default:
+			c.event(v, docURL)
+		case "Hangup", "Reject", "Leave":
+			c.event(v, docURL)
+			return nil
+		case "Redirect":
+			c.event(v, docURL)
+			c.next(docURL, v.attr("method"), strings.TrimSpace(v.Text), nil)
+			return nil
+		case "Gather":
+			c.event(v, docURL)
+			for _, nested := range v.Children {
+				c.event(nested, docURL)
+			}
+			in, ok := c.input()
+			if ok {
+				c.t.Events = append(c.t.Events, Event{Verb: inputVerb,
+					Text: in.Digits + in.Speech, URL: docURL})
+				params := url.Values{}
+				if in.Digits != "" {
+					params.Set(twiml.TwiDigits, in.Digits)
+				}
+				if in.Speech != "" {
+					params.Set(twiml.TwiSpeechResult, in.Speech)
+					params.Set(twiml.TwiConfidence,
+						strconv.FormatFloat(in.Confidence, 'f', -1, 64))
+				}
+				c.next(docURL, v.attr("method"), v.attr("action"), params)
+				return nil
+			}
+			if v.attr("actionOnEmptyResult") == "true" {
+				c.next(docURL, v.attr("method"), v.attr("action"), url.Values{})
+				return nil
+			}
+		case "Record":
+			c.event(v, docURL)
+			in, _ := c.input()
+			if in.Duration == 0 {
+				in.Duration = 5 * time.Second
+			}
+			recordingSid := newSid("RE")
+			recordingURL := "https://api.twilio.com/" + apiVersion +
+				"/Accounts/" + c.sim.AccountSid + "/Recordings/" + recordingSid
+			params := url.Values{
+				twiml.TwiRecordingSid: {recordingSid},
+				twiml.TwiRecordingUrl: {recordingURL},
+				twiml.TwiRecordingDuration: {strconv.Itoa(
+					int(in.Duration.Seconds()))},
+			}
+			if in.Digits != "" {
+				params.Set(twiml.TwiDigits, in.Digits)
+			}
+			if cb := v.attr("transcribeCallback"); cb != "" {
+				if _, err := c.post(resolve(docURL, cb), url.Values{
+					twiml.TwiTranscriptionSid:    {newSid("TR")},
+					twiml.TwiTranscriptionStatus: {twiml.TwiCompleted},
+					twiml.TwiTranscriptionText:   {in.Speech},
+					twiml.TwiRecordingSid:        {recordingSid},
+					twiml.TwiRecordingUrl:        {recordingURL},
+				}); err != nil {
+					return err
+				}
+			}
+			c.next(docURL, v.attr("method"), v.attr("action"), params)
+			return nil
+		case "Dial":
+			c.event(v, docURL)
+			if action := v.attr("action"); action != "" {
+				status := c.sim.DialCallStatus
+				if status == "" {
+					status = twiml.TwiCompleted
+				}
+				c.next(docURL, v.attr("method"), action, url.Values{
+					"DialCallStatus":   {status},
+					"DialCallSid":      {newSid("CA")},
+					"DialCallDuration": {"0"},
+				})
+				return nil
+			}
+		case "Enqueue":
+			c.event(v, docURL)
+			if action := v.attr("action"); action != "" {
+				result := c.sim.QueueResult
+				if result == "" {
+					result = "hangup"
+				}
+				c.next(docURL, v.attr("method"), action, url.Values{
+					"QueueResult": {result},
+					"QueueSid":    {newSid("QU")},
+					"QueueTime":   {"0"},
+				})
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// next continues the call with the document at target, relative to
+// docURL. An empty target is the current document.
+func (c *call) next(docURL, method, target string, params url.Values) {
+	if method == "" {
+		method = "POST"
+	}
+	c.redirect = &redirect{method: method, url: resolve(docURL, target),
+		params: params}
+}
+
+// input consumes the next scripted input. It reports false if the caller
+// stays silent.
+func (c *call) input() (Input, bool) {
+	if len(c.inputs) == 0 {
+		return NoInput, false
+	}
+	in := c.inputs[0]
+	c.inputs = c.inputs[1:]
+	return in, in != NoInput
+}
+
+// request makes a voice request and parses the returned TwiML
+func (c *call) request(method, target string, params url.Values) (*element, error) {
+	resp, err := c.do(method, target, params)
+	if err != nil {
+		return nil, err
+	}
+	doc := &element{}
+	if err := xml.Unmarshal(resp.Body.Bytes(), doc); err != nil {
+		return nil, fmt.Errorf("%s %s: parsing TwiML: %v", method, target, err)
+	}
+	if doc.XMLName.Local != "Response" {
+		return nil, fmt.Errorf("%s %s: root element is '%s', not Response",
+			method, target, doc.XMLName.Local)
+	}
+	return doc, nil
+}
+
+// post makes a callback request, ignoring the response body
+func (c *call) post(target string, params url.Values) (*httptest.ResponseRecorder, error) {
+	return c.do("POST", target, params)
+}
+
+// do sends a request with the call's voice parameters to the handler
+func (c *call) do(method, target string, params url.Values) (
+	*httptest.ResponseRecorder, error) {
+
+	c.t.Requests++
+	form := url.Values{
+		twiml.TwiCallSid:    {c.sid},
+		twiml.TwiAccountSid: {c.sim.AccountSid},
+		twiml.TwiFrom:       {c.sim.From},
+		twiml.TwiTo:         {c.sim.To},
+		twiml.TwiCallStatus: {c.status},
+		twiml.TwiDirection:  {twiml.TwiInbound},
+		twiml.TwiApiVersion: {apiVersion},
+	}
+	for k, v := range c.sim.Params {
+		form[k] = v
+	}
+	for k, v := range params {
+		form[k] = v
+	}
+
+	var r *http.Request
+	if strings.ToUpper(method) == "GET" {
+		u, err := url.Parse(target)
+		if err != nil {
+			return nil, err
+		}
+		q := u.Query()
+		for k, v := range form {
+			q[k] = v
+		}
+		u.RawQuery = q.Encode()
+		r = httptest.NewRequest("GET", u.String(), nil)
+		form = url.Values{}
+		target = u.String()
+	} else {
+		r = httptest.NewRequest("POST", target, strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if c.sim.AuthToken != "" {
+		r.Header.Set(twilio.SignatureHeader,
+			twilio.Signature(c.sim.AuthToken, target, form))
+	}
+
+	w := httptest.NewRecorder()
+	c.sim.Handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK && w.Code != http.StatusNoContent {
+		return nil, fmt.Errorf("%s %s: status %d: %s", method, target, w.Code,
+			strings.TrimSpace(w.Body.String()))
+	}
+	return w, nil
+}
+
+// resolve resolves target relative to the document url base
+func resolve(base, target string) string {
+	b, err := url.Parse(base)
+	if err != nil {
+		return target
+	}
+	t, err := url.Parse(target)
+	if err != nil {
+		return target
+	}
+	return b.ResolveReference(t).String()
+}
+
+// newSid returns a random sid with the given prefix
+func newSid(prefix string) string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return prefix + hex.EncodeToString(b)
+}
@@ -0,0 +1,214 @@
+package callsim_test
+
+import (
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tmc/twilio/callsim"
+	"github.com/tmc/twilio/ivr"
+	"github.com/tmc/twilio/twiml"
+)
+
+func TestIVRCall(t *testing.T) {
+	flow := &ivr.Flow{
+		Start: "main",
+		Nodes: map[string]*ivr.Node{
+			"main": {
+				Prompt:        []interface{}{twiml.Say{Text: "Press 1 for sales."}},
+				Input:         &twiml.Gather{NumDigits: 1},
+				Branches:      []ivr.Branch{{Digits: "1", Next: "sales"}},
+				Retries:       1,
+				NoInputPrompt: []interface{}{twiml.Say{Text: "Are you there?"}},
+			},
+			"sales": {
+				Actions: []interface{}{twiml.Dial{Number: "+15005550006"}},
+			},
+		},
+	}
+	h, err := ivr.NewHandler(flow, ivr.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.AuthToken = "secret"
+
+	sim := &callsim.Simulator{
+		Handler:    h,
+		URL:        "https://example.com/ivr",
+		AuthToken:  "secret",
+		AccountSid: "AC123",
+		From:       "+15005550001",
+		To:         "+15005550002",
+	}
+
+	tr, err := sim.Call(callsim.NoInput, callsim.Digits("1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	heard := []string{"Press 1 for sales.", "Are you there?", "Press 1 for sales."}
+	if got := tr.Heard(); !reflect.DeepEqual(got, heard) {
+		t.Errorf("heard %q, want %q", got, heard)
+	}
+	if got := tr.Events[len(tr.Events)-1].Targets; !reflect.DeepEqual(got, []string{"Number +15005550006"}) {
+		t.Errorf("dialed %q\n%s", got, tr)
+	}
+
+	// a silent caller exhausts the retries and is hung up on
+	tr, err = sim.Call()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verbs := tr.Verbs(); verbs[len(verbs)-1] != "Hangup" {
+		t.Errorf("silent call ended with %v", verbs)
+	}
+}
+
+// app serves fixed TwiML documents by path and records the requests made
+type app struct {
+	docs     map[string]string
+	requests []*http.Request
+}
+
+func (a *app) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	a.requests = append(a.requests, r)
+	doc, ok := a.docs[r.URL.Path]
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "text/xml")
+	io.WriteString(w, "<Response>"+doc+"</Response>")
+}
+
+// request returns the last request made to path
+func (a *app) request(path string) *http.Request {
+	for i := len(a.requests) - 1; i >= 0; i-- {
+		if a.requests[i].URL.Path == path {
+			return a.requests[i]
+		}
+	}
+	return nil
+}
+
+// simulate places a call to an app serving docs
+func simulate(t *testing.T, docs map[string]string, inputs ...callsim.Input) (
+	*app, *callsim.Transcript) {
+
+	t.Helper()
+	a := &app{docs: docs}
+	sim := &callsim.Simulator{Handler: a, URL: "https://example.com/voice",
+		AccountSid: "AC123", From: "+15005550001", To: "+15005550002"}
+	tr, err := sim.Call(inputs...)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, tr)
+	}
+	return a, tr
+}
+
+func TestRecord(t *testing.T) {
+	a, tr := simulate(t, map[string]string{
+		"/voice":    `<Record action="/recorded" transcribeCallback="/transcribed"/>`,
+		"/recorded": `<Hangup/>`,
+	}, callsim.Input{Speech: "call me back", Digits: "#", Duration: 12 * time.Second})
+
+	r := a.request("/recorded")
+	if r == nil || r.PostForm.Get("RecordingDuration") != "12" || r.PostForm.Get("Digits") != "#" ||
+		!strings.HasPrefix(r.PostForm.Get("RecordingSid"), "RE") ||
+		!strings.HasSuffix(r.PostForm.Get("RecordingUrl"), "/Accounts/AC123/Recordings/"+r.PostForm.Get("RecordingSid")) {
+		t.Fatalf("record action %v", r)
+	}
+	cb := a.request("/transcribed")
+	if cb == nil || cb.PostForm.Get("TranscriptionText") != "call me back" ||
+		cb.PostForm.Get("TranscriptionStatus") != "completed" ||
+		cb.PostForm.Get("RecordingSid") != r.PostForm.Get("RecordingSid") {
+		t.Errorf("transcription callback %v", cb)
+	}
+	if verbs := tr.Verbs(); !reflect.DeepEqual(verbs, []string{"Record", "Hangup"}) {
+		t.Errorf("verbs %v", verbs)
+	}
+}
+
+func TestDialAction(t *testing.T) {
+	docs := map[string]string{
+		"/voice":  `<Dial action="/dialed" method="POST"><Number>+15005550006</Number></Dial><Say>Not reached</Say>`,
+		"/dialed": `<Say>Bye</Say>`,
+	}
+	a := &app{docs: docs}
+	sim := &callsim.Simulator{Handler: a, URL: "https://example.com/voice",
+		AccountSid: "AC123", DialCallStatus: "busy"}
+	tr, err := sim.Call()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := a.request("/dialed")
+	if r == nil || r.PostForm.Get("DialCallStatus") != "busy" ||
+		!strings.HasPrefix(r.PostForm.Get("DialCallSid"), "CA") {
+		t.Fatalf("dial action %v", r)
+	}
+	if heard := tr.Heard(); !reflect.DeepEqual(heard, []string{"Bye"}) {
+		t.Errorf("heard %q", heard)
+	}
+}
+
+func TestStatusCallback(t *testing.T) {
+	a := &app{docs: map[string]string{"/voice": `<Say>Hi</Say>`}}
+	sim := &callsim.Simulator{Handler: a, URL: "https://example.com/voice",
+		AccountSid: "AC123", StatusCallback: "https://example.com/status"}
+	tr, err := sim.Call()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := a.request("/status")
+	if r == nil || r.PostForm.Get("CallStatus") != "completed" ||
+		r.PostForm.Get("CallSid") != tr.CallSid || r.PostForm.Get("CallDuration") == "" {
+		t.Fatalf("status callback %v", r)
+	}
+	if tr.Requests != 2 {
+		t.Errorf("%d requests", tr.Requests)
+	}
+}
+
+func TestSpeechInput(t *testing.T) {
+	a, tr := simulate(t, map[string]string{
+		"/voice": `<Gather input="speech" action="/heard"><Say>How can I help?</Say></Gather>`,
+		"/heard": `<Hangup/>`,
+	}, callsim.Speech("billing"))
+
+	r := a.request("/heard")
+	if r == nil || r.PostForm.Get("SpeechResult") != "billing" ||
+		r.PostForm.Get("Confidence") != "0.9" || r.PostForm.Get("Digits") != "" {
+		t.Fatalf("gather action %v", r)
+	}
+	if ev := tr.Events[2]; ev.Verb != "Input" || ev.Text != "billing" {
+		t.Errorf("input event %v", ev)
+	}
+}
+
+func TestGetMethod(t *testing.T) {
+	a, _ := simulate(t, map[string]string{
+		"/voice": `<Gather action="/menu?lang=en" method="GET"/>`,
+		"/menu":  `<Hangup/>`,
+	}, callsim.Digits("2"))
+
+	r := a.request("/menu")
+	if r == nil || r.Method != "GET" || r.URL.Query().Get("Digits") != "2" ||
+		r.URL.Query().Get("lang") != "en" || r.URL.Query().Get("CallSid") == "" {
+		t.Fatalf("gather action %v", r)
+	}
+}
+
+func TestMaxRequests(t *testing.T) {
+	a := &app{docs: map[string]string{"/voice": `<Say>Again</Say><Redirect/>`}}
+	sim := &callsim.Simulator{Handler: a, URL: "https://example.com/voice", MaxRequests: 3}
+	tr, err := sim.Call()
+	if err == nil || err.Error() != "call exceeded 3 requests" {
+		t.Errorf("error %v", err)
+	}
+	if tr.Requests != 3 || len(tr.Heard()) != 3 {
+		t.Errorf("%d requests, heard %q", tr.Requests, tr.Heard())
+	}
+}
@@ -0,0 +1,75 @@
+package callsim
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Event is something that happened during a simulated call, one per TwiML
+// verb executed and per scripted input given.
+type Event struct {
+	// Verb is the TwiML element executed, e.g. "Say", or "Input" for
+	// scripted caller input.
+	Verb string
+	// Text is the chardata of the verb, e.g. the text of a Say or the url of
+	// a Play, or the caller input.
+	Text string
+	// Attrs holds the attributes of the verb.
+	Attrs map[string]string
+	// Targets holds the nouns of a Dial, e.g. "Number +15005550006".
+	Targets []string
+	// URL is the document the verb came from.
+	URL string
+}
+
+func (e Event) String() string {
+	s := e.Verb
+	if e.Text != "" {
+		s += ": " + e.Text
+	}
+	if len(e.Targets) > 0 {
+		s += " [" + strings.Join(e.Targets, ", ") + "]"
+	}
+	return s
+}
+
+// Transcript records a simulated call.
+type Transcript struct {
+	CallSid string
+	Events  []Event
+	// Requests counts the webhook requests made to the handler, including
+	// status callbacks.
+	Requests int
+}
+
+// Heard returns what the caller heard: the text of each Say and the url of
+// each Play, in order.
+func (t *Transcript) Heard() []string {
+	var heard []string
+	for _, e := range t.Events {
+		if e.Verb == "Say" || e.Verb == "Play" {
+			heard = append(heard, e.Text)
+		}
+	}
+	return heard
+}
+
+// Verbs returns the names of the executed verbs, in order.
+func (t *Transcript) Verbs() []string {
+	var verbs []string
+	for _, e := range t.Events {
+		if e.Verb != inputVerb {
+			verbs = append(verbs, e.Verb)
+		}
+	}
+	return verbs
+}
+
+// String formats the transcript with one event per line.
+func (t *Transcript) String() string {
+	var b strings.Builder
+	for _, e := range t.Events {
+		fmt.Fprintln(&b, e)
+	}
+	return b.String()
+}
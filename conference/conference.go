@@ -0,0 +1,277 @@
+// Package conference keeps a live roster of conferences from the status
+// callbacks twilio sends to the statusCallback of a Conference: who is in
+// each conference, who is muted, on hold, coaching or speaking. The roster
+// can be reconciled with the participants twilio lists, for callbacks that
+// were lost.
+package conference
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tmc/twilio"
+	"github.com/tmc/twilio/twiml"
+	"github.com/tmc/twilio/twirest"
+)
+
+// Participant is the state of a call in a conference.
+type Participant struct {
+	CallSid  string
+	Label    string
+	Joined   time.Time
+	Muted    bool
+	Hold     bool
+	Speaking bool
+	// Coaching is set when the participant coaches the call CallSidToCoach.
+	Coaching       bool
+	CallSidToCoach string
+}
+
+// Conference is the state of a conference in progress.
+type Conference struct {
+	Sid          string
+	FriendlyName string
+	Started      time.Time
+	// Participants are ordered by the time they joined.
+	Participants []Participant
+}
+
+// conference is a conference in the roster
+type conference struct {
+	Conference
+	participants map[string]*Participant
+	sequence     int // sequence number of the last applied event
+}
+
+// Requester makes twilio REST requests, as *twirest.TwilioClient does.
+type Requester interface {
+	Request(reqStruct interface{}) (twirest.TwilioResponse, error)
+}
+
+// Roster is an http.Handler for conference status callbacks, keeping the
+// state of the conferences in progress in memory.
+type Roster struct {
+	// Validator verifies the signature of each callback.
+	twilio.Validator
+	// OnEvent, if set, is called with each event once the roster is
+	// updated, e.g. to push changes to a dashboard.
+	OnEvent func(*twiml.ConferenceStatusRequest)
+
+	mu          sync.Mutex
+	conferences map[string]*conference
+	ended       map[string]tombstone
+}
+
+// endedTTL is how long ended conferences are remembered, to drop retried
+// and late deliveries of their events
+const endedTTL = time.Hour
+
+// tombstone records an ended conference
+type tombstone struct {
+	sequence int // sequence number of the conference-end event
+	ended    time.Time
+}
+
+// NewRoster creates an empty roster.
+func NewRoster() *Roster {
+	return &Roster{}
+}
+
+// ServeHTTP parses a conference status callback and applies it to the
+// roster.
+func (ro *Roster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !ro.Verify(w, r) {
+		return
+	}
+	ev, err := twiml.ParseConferenceStatusRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if ro.Apply(ev) && ro.OnEvent != nil {
+		ro.OnEvent(ev)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Apply updates the roster with an event. Events older than the last one
+// applied to the conference, including retried deliveries and events
+// arriving after the conference ended, are dropped and Apply returns false.
+func (ro *Roster) Apply(ev *twiml.ConferenceStatusRequest) bool {
+	ro.mu.Lock()
+	defer ro.mu.Unlock()
+	if t, ok := ro.ended[ev.ConferenceSid]; ok {
+		if ev.SequenceNumber == 0 || ev.SequenceNumber <= t.sequence {
+			return false
+		}
+		delete(ro.ended, ev.ConferenceSid)
+	}
+	c := ro.conferences[ev.ConferenceSid]
+	if c == nil {
+		c = ro.add(ev.ConferenceSid)
+	}
+	if ev.SequenceNumber != 0 {
+		if ev.SequenceNumber <= c.sequence {
+			return false
+		}
+		c.sequence = ev.SequenceNumber
+	}
+	if ev.FriendlyName != "" {
+		c.FriendlyName = ev.FriendlyName
+	}
+
+	switch ev.StatusCallbackEvent {
+	case twiml.TwiEventConferenceStart:
+		c.Started = ev.Timestamp
+	case twiml.TwiEventConferenceEnd:
+		ro.end(ev.ConferenceSid, c.sequence)
+	case twiml.TwiEventParticipantLeave:
+		delete(c.participants, ev.CallSid)
+	case twiml.TwiEventParticipantJoin, twiml.TwiEventParticipantMute,
+		twiml.TwiEventParticipantUnmute, twiml.TwiEventParticipantHold,
+		twiml.TwiEventParticipantUnhold, twiml.TwiEventParticipantModify:
+		p := c.participant(ev.CallSid)
+		if ev.StatusCallbackEvent == twiml.TwiEventParticipantJoin {
+			p.Joined = ev.Timestamp
+		}
+		if ev.ParticipantLabel != "" {
+			p.Label = ev.ParticipantLabel
+		}
+		p.Muted, p.Hold = ev.Muted, ev.Hold
+		p.Coaching, p.CallSidToCoach = ev.Coaching, ev.CallSidToCoach
+	case twiml.TwiEventParticipantSpeechStart, twiml.TwiEventParticipantSpeechStop:
+		c.participant(ev.CallSid).Speaking =
+			ev.StatusCallbackEvent == twiml.TwiEventParticipantSpeechStart
+	}
+	return true
+}
+
+// add adds an empty conference to the roster
+func (ro *Roster) add(sid string) *conference {
+	if ro.conferences == nil {
+		ro.conferences = make(map[string]*conference)
+	}
+	c := &conference{
+		Conference:   Conference{Sid: sid},
+		participants: make(map[string]*Participant),
+	}
+	ro.conferences[sid] = c
+	return c
+}
+
+// end removes a conference from the roster, leaving a tombstone, and drops
+// the expired tombstones
+func (ro *Roster) end(sid string, sequence int) {
+	now := time.Now()
+	for s, t := range ro.ended {
+		if now.Sub(t.ended) > endedTTL {
+			delete(ro.ended, s)
+		}
+	}
+	if ro.ended == nil {
+		ro.ended = make(map[string]tombstone)
+	}
+	delete(ro.conferences, sid)
+	ro.ended[sid] = tombstone{sequence, now}
+}
+
+// participant returns the participant on the call callSid, adding it if
+// the conference has none
+func (c *conference) participant(callSid string) *Participant {
+	p := c.participants[callSid]
+	if p == nil {
+		p = &Participant{CallSid: callSid}
+		c.participants[callSid] = p
+	}
+	return p
+}
+
+// snapshot returns a copy of the conference
+func (c *conference) snapshot() Conference {
+	s := c.Conference
+	s.Participants = make([]Participant, 0, len(c.participants))
+	for _, p := range c.participants {
+		s.Participants = append(s.Participants, *p)
+	}
+	sort.Slice(s.Participants, func(i, j int) bool {
+		pi, pj := s.Participants[i], s.Participants[j]
+		if !pi.Joined.Equal(pj.Joined) {
+			return pi.Joined.Before(pj.Joined)
+		}
+		return pi.CallSid < pj.CallSid
+	})
+	return s
+}
+
+// Conference returns the state of the conference sid, false if it is not in
+// progress.
+func (ro *Roster) Conference(sid string) (Conference, bool) {
+	ro.mu.Lock()
+	defer ro.mu.Unlock()
+	c := ro.conferences[sid]
+	if c == nil {
+		return Conference{}, false
+	}
+	return c.snapshot(), true
+}
+
+// Conferences returns the state of the conferences in progress, ordered by
+// sid.
+func (ro *Roster) Conferences() []Conference {
+	ro.mu.Lock()
+	defer ro.mu.Unlock()
+	cs := make([]Conference, 0, len(ro.conferences))
+	for _, c := range ro.conferences {
+		cs = append(cs, c.snapshot())
+	}
+	sort.Slice(cs, func(i, j int) bool { return cs[i].Sid < cs[j].Sid })
+	return cs
+}
+
+// Reconcile replaces the participants of the conference sid with those
+// twilio lists, following the pages of the listing and keeping the state
+// callbacks told of calls still present. The speaking and hold state of
+// calls missing from the roster is not known.
+func (ro *Roster) Reconcile(client Requester, sid string) error {
+	var listed []twirest.ParticipantResponse
+	req := twirest.Participants{Sid: sid}
+	for {
+		resp, err := client.Request(req)
+		if err != nil {
+			return err
+		}
+		if resp.Participants == nil {
+			break
+		}
+		listed = append(listed, resp.Participants.Participant...)
+		if resp.Participants.NextPageUri == "" {
+			break
+		}
+		req.Page = strconv.Itoa(int(resp.Participants.Page.Page) + 1)
+	}
+
+	ro.mu.Lock()
+	defer ro.mu.Unlock()
+	c := ro.conferences[sid]
+	if c == nil {
+		if _, ended := ro.ended[sid]; ended || len(listed) == 0 {
+			return nil
+		}
+		c = ro.add(sid)
+	}
+	participants := make(map[string]*Participant, len(listed))
+	for _, l := range listed {
+		p := c.participants[l.CallSid]
+		if p == nil {
+			p = &Participant{CallSid: l.CallSid}
+			p.Joined, _ = time.Parse(time.RFC1123Z, l.DateCreated)
+		}
+		p.Muted, _ = strconv.ParseBool(l.Muted)
+		participants[l.CallSid] = p
+	}
+	c.participants = participants
+	return nil
+}
@@ -0,0 +1,216 @@
+package conference_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/tmc/twilio/conference"
+	"github.com/tmc/twilio/twiml"
+	"github.com/tmc/twilio/twiml/twimltest"
+	"github.com/tmc/twilio/twirest"
+)
+
+const (
+	confSid = "CF0123456789abcdef0123456789abcdef"
+	alice   = "CA00000000000000000000000000000001"
+	bob     = "CA00000000000000000000000000000002"
+)
+
+var seq int
+
+// event posts a status callback to the roster
+func event(t *testing.T, ro *conference.Roster, ev string, callSid string, params url.Values) int {
+	seq++
+	form := url.Values{
+		"ConferenceSid":       {confSid},
+		"FriendlyName":        {"support"},
+		"StatusCallbackEvent": {ev},
+		"SequenceNumber":      {strconv.Itoa(seq)},
+		"Timestamp":           {"Mon, 19 Oct 2026 10:00:0" + strconv.Itoa(seq%10) + " +0000"},
+		"CallSid":             {callSid},
+		"Muted":               {"false"},
+		"Hold":                {"false"},
+	}
+	for k, v := range params {
+		form[k] = v
+	}
+	w := httptest.NewRecorder()
+	ro.ServeHTTP(w, twimltest.NewRequest("/conference", form))
+	return w.Code
+}
+
+func TestRoster(t *testing.T) {
+	seq = 0
+	var events []twiml.ConferenceEvent
+	ro := conference.NewRoster()
+	ro.OnEvent = func(ev *twiml.ConferenceStatusRequest) {
+		events = append(events, ev.StatusCallbackEvent)
+	}
+	event(t, ro, twiml.TwiEventParticipantJoin, alice, url.Values{"ParticipantLabel": {"agent"}})
+	event(t, ro, twiml.TwiEventConferenceStart, "", nil)
+	event(t, ro, twiml.TwiEventParticipantJoin, bob, nil)
+	event(t, ro, twiml.TwiEventParticipantMute, bob, url.Values{"Muted": {"true"}})
+	event(t, ro, twiml.TwiEventParticipantSpeechStart, alice, nil)
+
+	c, ok := ro.Conference(confSid)
+	if !ok || c.FriendlyName != "support" || c.Started.IsZero() || len(c.Participants) != 2 {
+		t.Fatalf("conference %+v", c)
+	}
+	a, b := c.Participants[0], c.Participants[1]
+	if a.CallSid != alice || a.Label != "agent" || !a.Speaking || a.Muted {
+		t.Errorf("alice %+v", a)
+	}
+	if b.CallSid != bob || !b.Muted || b.Speaking {
+		t.Errorf("bob %+v", b)
+	}
+
+	// a retried delivery is dropped
+	stale := &twiml.ConferenceStatusRequest{ConferenceSid: confSid, CallSid: bob,
+		StatusCallbackEvent: twiml.TwiEventParticipantUnmute, SequenceNumber: 2}
+	if ro.Apply(stale) {
+		t.Error("stale event applied")
+	}
+	if c, _ := ro.Conference(confSid); !c.Participants[1].Muted {
+		t.Error("stale event unmuted bob")
+	}
+
+	event(t, ro, twiml.TwiEventParticipantLeave, bob, nil)
+	if c, _ := ro.Conference(confSid); len(c.Participants) != 1 {
+		t.Errorf("participants %+v", c.Participants)
+	}
+	event(t, ro, twiml.TwiEventConferenceEnd, "", nil)
+	if _, ok := ro.Conference(confSid); ok || len(ro.Conferences()) != 0 {
+		t.Error("ended conference in roster")
+	}
+
+	// a retried end and a late event are dropped
+	end := &twiml.ConferenceStatusRequest{ConferenceSid: confSid,
+		StatusCallbackEvent: twiml.TwiEventConferenceEnd, SequenceNumber: seq}
+	late := &twiml.ConferenceStatusRequest{ConferenceSid: confSid, CallSid: bob,
+		StatusCallbackEvent: twiml.TwiEventParticipantMute, SequenceNumber: seq - 1}
+	if ro.Apply(end) || ro.Apply(late) {
+		t.Error("event of ended conference applied")
+	}
+	if len(ro.Conferences()) != 0 {
+		t.Error("ended conference created again")
+	}
+	if len(events) != 7 {
+		t.Errorf("events %v", events)
+	}
+}
+
+func TestRosterEndOfUnknownConference(t *testing.T) {
+	seq = 10
+	var events int
+	ro := conference.NewRoster()
+	ro.OnEvent = func(*twiml.ConferenceStatusRequest) { events++ }
+
+	form := url.Values{"SequenceNumber": {"10"}}
+	for i := 0; i < 2; i++ {
+		if code := event(t, ro, twiml.TwiEventConferenceEnd, "", form); code != http.StatusNoContent {
+			t.Fatalf("status %d", code)
+		}
+	}
+	if events != 1 {
+		t.Errorf("OnEvent called %d times", events)
+	}
+	event(t, ro, twiml.TwiEventParticipantLeave, alice, url.Values{"SequenceNumber": {"9"}})
+	if len(ro.Conferences()) != 0 {
+		t.Error("ended conference created again")
+	}
+
+	client := &fakeClient{pages: []twirest.TwilioResponse{{
+		Participants: &twirest.ParticipantsResponse{Participant: []twirest.ParticipantResponse{
+			{ConferenceSid: confSid, CallSid: alice}}},
+	}}}
+	if err := ro.Reconcile(client, confSid); err != nil || len(ro.Conferences()) != 0 {
+		t.Errorf("reconcile created ended conference: %v", err)
+	}
+}
+
+func TestRosterBadRequest(t *testing.T) {
+	ro := conference.NewRoster()
+	if code := event(t, ro, "ringing", alice, nil); code != http.StatusBadRequest {
+		t.Errorf("status %d", code)
+	}
+	ro.AuthToken = "secret"
+	if code := event(t, ro, twiml.TwiEventParticipantJoin, alice, nil); code != http.StatusForbidden {
+		t.Errorf("status %d", code)
+	}
+	if len(ro.Conferences()) != 0 {
+		t.Error("rejected events applied")
+	}
+}
+
+// fakeClient returns its pages in turn and records the requests made
+type fakeClient struct {
+	pages []twirest.TwilioResponse
+	err   error
+	reqs  []interface{}
+}
+
+func (f *fakeClient) Request(req interface{}) (twirest.TwilioResponse, error) {
+	f.reqs = append(f.reqs, req)
+	if f.err != nil || len(f.pages) == 0 {
+		return twirest.TwilioResponse{}, f.err
+	}
+	resp := f.pages[0]
+	f.pages = f.pages[1:]
+	return resp, nil
+}
+
+func TestReconcile(t *testing.T) {
+	seq = 0
+	ro := conference.NewRoster()
+	event(t, ro, twiml.TwiEventParticipantJoin, alice, url.Values{"ParticipantLabel": {"agent"}})
+	event(t, ro, twiml.TwiEventParticipantSpeechStart, alice, nil)
+	event(t, ro, twiml.TwiEventParticipantJoin, bob, nil)
+
+	// bob's leave was lost, carol's join too
+	carol := "CA00000000000000000000000000000003"
+	client := &fakeClient{pages: []twirest.TwilioResponse{{
+		Participants: &twirest.ParticipantsResponse{
+			Page: twirest.Page{Page: 0, NextPageUri: "/Participants?Page=1"},
+			Participant: []twirest.ParticipantResponse{
+				{ConferenceSid: confSid, CallSid: alice, Muted: "true"}},
+		},
+	}, {
+		Participants: &twirest.ParticipantsResponse{
+			Page: twirest.Page{Page: 1},
+			Participant: []twirest.ParticipantResponse{
+				{ConferenceSid: confSid, CallSid: carol, Muted: "false",
+					DateCreated: "Mon, 19 Oct 2026 10:05:00 +0000"}},
+		},
+	}}}
+	if err := ro.Reconcile(client, confSid); err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{twirest.Participants{Sid: confSid},
+		twirest.Participants{Sid: confSid, Page: "1"}}
+	if !reflect.DeepEqual(client.reqs, want) {
+		t.Errorf("requests %#v", client.reqs)
+	}
+	c, _ := ro.Conference(confSid)
+	if len(c.Participants) != 2 {
+		t.Fatalf("participants %+v", c.Participants)
+	}
+	if a := c.Participants[0]; a.CallSid != alice || a.Label != "agent" || !a.Speaking || !a.Muted {
+		t.Errorf("alice %+v", a)
+	}
+	if p := c.Participants[1]; p.CallSid != carol || p.Joined.IsZero() {
+		t.Errorf("carol %+v", p)
+	}
+
+	client.err = errors.New("unavailable")
+	if err := ro.Reconcile(client, confSid); err == nil {
+		t.Error("no error")
+	}
+	if c, _ := ro.Conference(confSid); len(c.Participants) != 2 {
+		t.Error("failed reconcile changed the roster")
+	}
+}
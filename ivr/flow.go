@@ -0,0 +1,141 @@
+// Package ivr builds multi-step phone menus on top of twiml.Gather. A menu is
+// declared as a Flow of named nodes and served by a Handler that renders the
+// TwiML for each step and keeps per-call state in a SessionStore.
+package ivr
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/tmc/twilio/twiml"
+)
+
+// Flow is a graph of named nodes. A call enters the flow at Start.
+type Flow struct {
+	Start string
+	Nodes map[string]*Node
+}
+
+// Node is a step of a flow. A node with Input asks the caller for digits or
+// speech and follows the matching Branch. A node without Input plays its
+// Prompt, executes its Actions and continues with Next, or ends the flow if
+// Next is empty.
+type Node struct {
+	// Conditions are checked on entering the node; the call continues with
+	// the Next node of the first matching condition instead.
+	Conditions []Condition
+	// Prompt holds the Say, Play and Pause verbs played on entering the
+	// node.
+	Prompt []interface{}
+	// Input configures the Gather collecting the caller's choice. Its
+	// Action and Method are set by the Handler.
+	Input *twiml.Gather
+	// Branches select the next node from the caller's input.
+	Branches []Branch
+	// Retries is how many times the node is repeated after no or invalid
+	// input before the flow continues with Fallback.
+	Retries int
+	// NoInputPrompt and InvalidPrompt are played before the Prompt when the
+	// node is repeated.
+	NoInputPrompt []interface{}
+	InvalidPrompt []interface{}
+	// Fallback is the node entered when retries are exhausted. The call is
+	// hung up if empty.
+	Fallback string
+	// Actions are the verbs executed after the Prompt of a node without
+	// Input, e.g. Dial, Enqueue or Hangup.
+	Actions []interface{}
+	// Next is the node entered after a node without Input.
+	Next string
+}
+
+// Branch maps caller input to the next node. Input matches if the pressed
+// digits equal Digits or the speech result contains one of the Speech
+// phrases, ignoring case.
+type Branch struct {
+	Digits string
+	Speech []string
+	Next   string
+}
+
+// Condition matches if the webhook parameter Param equals Value, or, when
+// Prefix is set, starts with Value.
+type Condition struct {
+	Param  string
+	Value  string
+	Prefix bool
+	Next   string
+}
+
+// match reports whether the condition holds for the webhook parameters
+func (c Condition) match(form url.Values) bool {
+	v := form.Get(c.Param)
+	if c.Prefix {
+		return strings.HasPrefix(v, c.Value)
+	}
+	return v == c.Value
+}
+
+// match reports whether the branch matches the caller input
+func (b Branch) match(digits, speech string) bool {
+	if digits != "" && digits == b.Digits {
+		return true
+	}
+	speech = strings.ToLower(speech)
+	for _, phrase := range b.Speech {
+		if speech != "" && strings.Contains(speech, strings.ToLower(phrase)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that the flow's start and every node reference exist and
+// that prompts and actions only hold verbs valid in their position.
+func (f *Flow) Validate() error {
+	if _, ok := f.Nodes[f.Start]; !ok {
+		return fmt.Errorf("start node not found: '%s'", f.Start)
+	}
+	for name, n := range f.Nodes {
+		if n == nil {
+			return fmt.Errorf("node '%s': nil node", name)
+		}
+		refs := []string{n.Fallback, n.Next}
+		for _, c := range n.Conditions {
+			if c.Param == "" || c.Next == "" {
+				return fmt.Errorf("node '%s': condition needs param and next node", name)
+			}
+			refs = append(refs, c.Next)
+		}
+		for _, b := range n.Branches {
+			if b.Next == "" {
+				return fmt.Errorf("node '%s': branch without next node", name)
+			}
+			refs = append(refs, b.Next)
+		}
+		for _, ref := range refs {
+			if _, ok := f.Nodes[ref]; ref != "" && !ok {
+				return fmt.Errorf("node '%s': node not found: '%s'", name, ref)
+			}
+		}
+		prompts := append(append(append([]interface{}{}, n.Prompt...),
+			n.NoInputPrompt...), n.InvalidPrompt...)
+		for _, p := range prompts {
+			switch p.(type) {
+			case twiml.Say, twiml.Play, twiml.Pause:
+			default:
+				return fmt.Errorf("node '%s': non valid prompt: '%T'", name, p)
+			}
+		}
+		if n.Input != nil && len(n.Actions) > 0 {
+			return fmt.Errorf("node '%s': input and actions are exclusive", name)
+		}
+		for _, a := range n.Actions {
+			if err := appendVerb(twiml.NewResponse(), a); err != nil {
+				return fmt.Errorf("node '%s': %v", name, err)
+			}
+		}
+	}
+	return nil
+}
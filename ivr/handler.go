@@ -0,0 +1,228 @@
+package ivr
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/tmc/twilio"
+	"github.com/tmc/twilio/twiml"
+)
+
+// Events passed in the event query parameter of the urls the handler points
+// twilio back to. A request without an event starts the flow.
+const (
+	eventParam   = "event"
+	eventInput   = "input"   // Gather action with the caller's input
+	eventNoInput = "noinput" // Gather timed out
+	eventEnter   = "enter"   // continue with the session's node
+)
+
+// Handler is an http.Handler serving a Flow. It must be the Url of the voice
+// application; all following requests of the call are pointed back at the
+// same path. It should also be the StatusCallback of the application, so the
+// session of a caller who hangs up before the flow finishes is dropped.
+type Handler struct {
+	Flow     *Flow
+	Sessions SessionStore
+	// Validator verifies the signature of each request.
+	twilio.Validator
+
+	mu sync.RWMutex // guards Flow once the handler is serving
+}
+
+// NewHandler validates flow and creates a handler keeping sessions in store.
+func NewHandler(flow *Flow, store SessionStore) (*Handler, error) {
+	if err := flow.Validate(); err != nil {
+		return nil, err
+	}
+	return &Handler{Flow: flow, Sessions: store}, nil
+}
+
+// SetFlow validates flow and replaces the flow served by the handler. Calls
+// in progress continue at their current node if it still exists and start
+// over otherwise.
+func (h *Handler) SetFlow(flow *Flow) error {
+	if err := flow.Validate(); err != nil {
+		return err
+	}
+	h.mu.Lock()
+	h.Flow = flow
+	h.mu.Unlock()
+	return nil
+}
+
+// ServeHTTP advances the call's session and writes the TwiML of its step.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.Verify(w, r) {
+		return
+	}
+	vr, err := twiml.ParseVoiceRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch vr.CallStatus {
+	case twiml.TwiCompleted, twiml.TwiBusy, twiml.TwiFailed, twiml.TwiNoAnswer,
+		twiml.TwiCanceled:
+		// the call ended, this is the status callback
+		if err := h.Sessions.Delete(vr.CallSid); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	h.mu.RLock()
+	c := &call{flow: h.Flow, sessions: h.Sessions, path: twilio.RequestPath(r), form: r.Form}
+	h.mu.RUnlock()
+
+	resp, err := c.step(r.URL.Query().Get(eventParam), vr)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/xml")
+	resp.Send(w)
+}
+
+// call holds what is needed to answer one request of a call
+type call struct {
+	flow     *Flow
+	sessions SessionStore
+	path     string     // url path twilio is pointed back to
+	form     url.Values // webhook parameters, for node conditions
+}
+
+// step updates the session for event and renders the resulting node
+func (c *call) step(event string, vr *twiml.VoiceRequest) (*twiml.Response, error) {
+	s, err := c.sessions.Get(vr.CallSid)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil || event == "" {
+		s = &Session{CallSid: vr.CallSid, Node: c.flow.Start}
+		return c.enter(s, nil)
+	}
+
+	n := c.flow.Nodes[s.Node]
+	if n == nil {
+		// the flow changed under a running call, start over
+		s = &Session{CallSid: vr.CallSid, Node: c.flow.Start}
+		return c.enter(s, nil)
+	}
+
+	switch event {
+	case eventInput:
+		if vr.Digits == "" && vr.SpeechResult == "" {
+			return c.retry(s, n, n.NoInputPrompt)
+		}
+		for _, b := range n.Branches {
+			if b.match(vr.Digits, vr.SpeechResult) {
+				input := vr.Digits
+				if input == "" {
+					input = vr.SpeechResult
+				}
+				s.record(s.Node, input)
+				s.Node, s.Retries = b.Next, 0
+				return c.enter(s, nil)
+			}
+		}
+		return c.retry(s, n, n.InvalidPrompt)
+	case eventNoInput:
+		return c.retry(s, n, n.NoInputPrompt)
+	}
+	return c.enter(s, nil)
+}
+
+// retry repeats node n after no or invalid input, or continues with its
+// fallback once the retries are exhausted
+func (c *call) retry(s *Session, n *Node, preamble []interface{}) (
+	*twiml.Response, error) {
+
+	if s.Retries < n.Retries {
+		s.Retries++
+		return c.enter(s, preamble)
+	}
+	if n.Fallback == "" {
+		if err := c.sessions.Delete(s.CallSid); err != nil {
+			return nil, err
+		}
+		resp := twiml.NewResponse()
+		resp.Action(twiml.Hangup{})
+		return resp, nil
+	}
+	s.Node, s.Retries = n.Fallback, 0
+	return c.enter(s, nil)
+}
+
+// enter renders the session's node and stores the session
+func (c *call) enter(s *Session, preamble []interface{}) (*twiml.Response, error) {
+	n := c.follow(s)
+	resp := twiml.NewResponse()
+	prompt := append(append([]interface{}{}, preamble...), n.Prompt...)
+
+	if n.Input != nil {
+		g := *n.Input
+		g.Action = c.path + "?" + eventParam + "=" + eventInput
+		g.Method = "POST"
+		if err := resp.Gather(append([]interface{}{g}, prompt...)...); err != nil {
+			return nil, err
+		}
+		resp.Action(twiml.Redirect{Method: "POST",
+			Url: c.path + "?" + eventParam + "=" + eventNoInput})
+		return resp, c.sessions.Put(s)
+	}
+
+	if err := resp.Action(prompt...); err != nil {
+		return nil, err
+	}
+	for _, a := range n.Actions {
+		if err := appendVerb(resp, a); err != nil {
+			return nil, err
+		}
+	}
+	if len(n.Actions) > 0 || n.Next == "" {
+		return resp, c.sessions.Delete(s.CallSid)
+	}
+	s.Node, s.Retries = n.Next, 0
+	resp.Action(twiml.Redirect{Method: "POST",
+		Url: c.path + "?" + eventParam + "=" + eventEnter})
+	return resp, c.sessions.Put(s)
+}
+
+// follow moves the session along the conditions of the nodes it enters and
+// returns the node to render
+func (c *call) follow(s *Session) *Node {
+	n := c.flow.Nodes[s.Node]
+	// bound the walk by the number of nodes so conditions can't loop forever
+	for i := 0; i < len(c.flow.Nodes); i++ {
+		next := ""
+		for _, cond := range n.Conditions {
+			if cond.match(c.form) {
+				next = cond.Next
+				break
+			}
+		}
+		if next == "" {
+			break
+		}
+		s.Node, s.Retries = next, 0
+		n = c.flow.Nodes[next]
+	}
+	return n
+}
+
+// appendVerb appends a verb to resp, using Response.Dial and Response.Gather
+// for verbs with nested nouns and verbs
+func appendVerb(resp *twiml.Response, v interface{}) error {
+	switch v := v.(type) {
+	case twiml.Dial:
+		return resp.Dial(append([]interface{}{v}, v.Nested...)...)
+	case twiml.Gather:
+		return resp.Gather(append([]interface{}{v}, v.Nested...)...)
+	}
+	return resp.Action(v)
+}
@@ -0,0 +1,160 @@
+package ivr_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tmc/twilio/ivr"
+	"github.com/tmc/twilio/twiml"
+)
+
+var flow = &ivr.Flow{
+	Start: "main",
+	Nodes: map[string]*ivr.Node{
+		"main": {
+			Prompt:        []interface{}{twiml.Say{Text: "Press 1 for sales."}},
+			Input:         &twiml.Gather{NumDigits: 1, Timeout: twiml.Int(5)},
+			Branches:      []ivr.Branch{{Digits: "1", Speech: []string{"sales"}, Next: "sales"}},
+			Retries:       1,
+			InvalidPrompt: []interface{}{twiml.Say{Text: "Sorry."}},
+			Fallback:      "bye",
+		},
+		"sales": {
+			Prompt:  []interface{}{twiml.Say{Text: "Connecting."}},
+			Actions: []interface{}{twiml.Dial{Number: "+15005550006"}},
+		},
+		"bye": {
+			Actions: []interface{}{twiml.Say{Text: "Goodbye."}, twiml.Hangup{}},
+		},
+	},
+}
+
+func call(t *testing.T, h *ivr.Handler, target string, form url.Values) string {
+	form.Set("CallSid", "CA123")
+	form.Set("AccountSid", "AC123")
+	r := httptest.NewRequest("POST", target, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != 200 {
+		t.Fatalf("%s: status %d: %s", target, w.Code, w.Body)
+	}
+	return w.Body.String()
+}
+
+func TestHandler(t *testing.T) {
+	h, err := ivr.NewHandler(flow, ivr.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		target string
+		form   url.Values
+		want   string
+	}{
+		{"/ivr", url.Values{}, `<Gather action="/ivr?event=input" method="POST" timeout="5" numDigits="1">`},
+		{"/ivr?event=input", url.Values{"Digits": {"9"}}, "Sorry."},
+		{"/ivr?event=input", url.Values{"SpeechResult": {"Sales please"}}, "+15005550006"},
+		{"/ivr", url.Values{}, "Press 1 for sales."},
+		{"/ivr?event=noinput", url.Values{}, "Press 1 for sales."},
+		{"/ivr?event=noinput", url.Values{}, "Goodbye."},
+	}
+	for i, s := range steps {
+		if got := call(t, h, s.target, s.form); !strings.Contains(got, s.want) {
+			t.Errorf("step %d: got %s, want %q", i, got, s.want)
+		}
+	}
+}
+
+// nilInputStore returns sessions without input, as a store serializing
+// empty maps as null would
+type nilInputStore struct{ *ivr.MemoryStore }
+
+func (s nilInputStore) Get(callSid string) (*ivr.Session, error) {
+	sess, err := s.MemoryStore.Get(callSid)
+	if sess != nil {
+		sess.Input = nil
+	}
+	return sess, err
+}
+
+func TestHandlerSessionWithoutInput(t *testing.T) {
+	h, err := ivr.NewHandler(flow, nilInputStore{ivr.NewMemoryStore()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	call(t, h, "/ivr", url.Values{})
+	if got := call(t, h, "/ivr?event=input", url.Values{"Digits": {"1"}}); !strings.Contains(got, "+15005550006") {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestHandlerHangup(t *testing.T) {
+	store := ivr.NewMemoryStore()
+	h, err := ivr.NewHandler(flow, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	call(t, h, "/ivr", url.Values{})
+	if s, _ := store.Get("CA123"); s == nil {
+		t.Fatal("no session")
+	}
+
+	form := url.Values{"CallSid": {"CA123"}, "AccountSid": {"AC123"},
+		"CallStatus": {"completed"}}
+	r := httptest.NewRequest("POST", "/ivr", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("status %d", w.Code)
+	}
+	if s, _ := store.Get("CA123"); s != nil {
+		t.Errorf("session %+v kept after hangup", s)
+	}
+}
+
+func TestMemoryStoreTTL(t *testing.T) {
+	store := ivr.NewMemoryStoreTTL(time.Millisecond)
+	store.Put(&ivr.Session{CallSid: "CA1", Node: "main"})
+	time.Sleep(2 * time.Millisecond)
+	if s, _ := store.Get("CA1"); s != nil {
+		t.Errorf("expired session %+v", s)
+	}
+	store.Put(&ivr.Session{CallSid: "CA2", Node: "main"})
+	if s, _ := store.Get("CA2"); s == nil || s.Node != "main" {
+		t.Errorf("session %+v", s)
+	}
+}
+
+func TestHandlerStripPrefix(t *testing.T) {
+	h, err := ivr.NewHandler(flow, ivr.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/voice/", http.StripPrefix("/voice", h))
+
+	form := url.Values{"CallSid": {"CA123"}, "AccountSid": {"AC123"}}
+	r := httptest.NewRequest("POST", "/voice/ivr", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	if want := `action="/voice/ivr?event=input"`; !strings.Contains(w.Body.String(), want) {
+		t.Errorf("%s not in %s", want, w.Body)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	f := &ivr.Flow{Start: "main", Nodes: map[string]*ivr.Node{
+		"main": {Next: "missing"},
+	}}
+	if err := f.Validate(); err == nil {
+		t.Error("missing node not reported")
+	}
+}
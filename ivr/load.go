@@ -0,0 +1,501 @@
+package ivr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/tmc/twilio/twiml"
+	"gopkg.in/yaml.v2"
+)
+
+// A flow definition file is written in JSON, or in YAML unless its name ends
+// in ".json":
+//
+//	start: main
+//	include: [common.yaml]   # files whose nodes and vars are merged in
+//	vars:
+//	  company: Acme
+//	nodes:
+//	  main:
+//	    when:                  # conditions on webhook parameters
+//	      - {param: FromCountry, value: CA, next: main-fr}
+//	    prompt:
+//	      - say: {text: "Welcome to ${company}", voice: alice}
+//	    gather: {numDigits: 1, timeout: 5}
+//	    branches:
+//	      - {digits: "1", speech: [sales], next: sales}
+//	    retries: 2
+//	    noInputPrompt: [{say: "Are you still there?"}]
+//	    invalidPrompt: [{play: "https://example.com/sorry.mp3"}]
+//	    fallback: goodbye
+//	  sales:
+//	    actions:
+//	      - dial:
+//	          callerId: "+15005550006"
+//	          nested: [{number: "+15005550001"}, {client: sales}]
+//	  goodbye:
+//	    actions: [{say: Goodbye}, hangup]
+//
+// Verbs are written as a single key object naming the verb, or as the bare
+// verb name when it has no attributes. Verb attributes are the field names of
+// the twiml vocabulary structs, and a string value sets the verb's text, url,
+// number or name. Dial, Gather, Connect, Start and Stop take their nouns and
+// verbs in nested.
+// ${name} is replaced with the value of a var throughout the definition.
+
+// flowDef is the decoded form of a flow definition file
+type flowDef struct {
+	Start   string             `json:"start"`
+	Include []string           `json:"include"`
+	Vars    map[string]string  `json:"vars"`
+	Nodes   map[string]nodeDef `json:"nodes"`
+}
+
+type nodeDef struct {
+	When          []Condition       `json:"when"`
+	Prompt        []json.RawMessage `json:"prompt"`
+	Gather        json.RawMessage   `json:"gather"`
+	Branches      []branchDef       `json:"branches"`
+	Retries       int               `json:"retries"`
+	NoInputPrompt []json.RawMessage `json:"noInputPrompt"`
+	InvalidPrompt []json.RawMessage `json:"invalidPrompt"`
+	Fallback      string            `json:"fallback"`
+	Actions       []json.RawMessage `json:"actions"`
+	Next          string            `json:"next"`
+}
+
+type branchDef struct {
+	Digits digits   `json:"digits"`
+	Speech []string `json:"speech"`
+	Next   string   `json:"next"`
+}
+
+// digits accepts keys written as numbers as well as strings, e.g. 1 and "#"
+type digits string
+
+func (d *digits) UnmarshalJSON(b []byte) error {
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err == nil {
+		*d = digits(n)
+		return nil
+	}
+	return json.Unmarshal(b, (*string)(d))
+}
+
+// verbs creates the vocabulary struct for each verb and noun name
+var verbs = map[string]func() interface{}{
+	"client":     func() interface{} { return new(twiml.Client) },
+	"conference": func() interface{} { return new(twiml.Conference) },
+	"connect":    func() interface{} { return new(twiml.Connect) },
+	"dial":       func() interface{} { return new(twiml.Dial) },
+	"enqueue":    func() interface{} { return new(twiml.Enqueue) },
+	"gather":     func() interface{} { return new(twiml.Gather) },
+	"hangup":     func() interface{} { return new(twiml.Hangup) },
+	"leave":      func() interface{} { return new(twiml.Leave) },
+	"message":    func() interface{} { return new(twiml.Message) },
+	"number":     func() interface{} { return new(twiml.Number) },
+	"pause":      func() interface{} { return new(twiml.Pause) },
+	"pay":        func() interface{} { return new(twiml.Pay) },
+	"play":       func() interface{} { return new(twiml.Play) },
+	"prompt":     func() interface{} { return new(twiml.Prompt) },
+	"queue":      func() interface{} { return new(twiml.Queue) },
+	"record":     func() interface{} { return new(twiml.Record) },
+	"redirect":   func() interface{} { return new(twiml.Redirect) },
+	"reject":     func() interface{} { return new(twiml.Reject) },
+	"say":        func() interface{} { return new(twiml.Say) },
+	"sip":        func() interface{} { return new(twiml.Sip) },
+	"start":      func() interface{} { return new(twiml.Start) },
+	"stop":       func() interface{} { return new(twiml.Stop) },
+	"stream":     func() interface{} { return new(twiml.Stream) },
+}
+
+// textFields names the chardata field set by a verb given as a string
+var textFields = map[string]string{
+	"client":     "Name",
+	"conference": "Name",
+	"dial":       "Number",
+	"enqueue":    "Name",
+	"message":    "Body",
+	"number":     "Number",
+	"play":       "Url",
+	"queue":      "Name",
+	"redirect":   "Url",
+	"say":        "Text",
+	"sip":        "Address",
+	"stream":     "Url",
+}
+
+// LoadFlow loads, compiles and validates the flow definition at path and the
+// files it includes.
+func LoadFlow(path string) (*Flow, error) {
+	f, _, err := loadFlow(path)
+	return f, err
+}
+
+// loadFlow loads a flow and returns the files it was read from
+func loadFlow(path string) (*Flow, []string, error) {
+	var defs []*flowDef
+	var files []string
+	if err := readDefs(path, map[string]bool{}, map[string]bool{}, &defs, &files); err != nil {
+		return nil, files, err
+	}
+
+	// included definitions come first, so the including file wins
+	vars := map[string]string{}
+	flow := &Flow{Nodes: map[string]*Node{}}
+	for _, d := range defs {
+		for k, v := range d.Vars {
+			vars[k] = v
+		}
+		if d.Start != "" {
+			flow.Start = d.Start
+		}
+	}
+	for i, d := range defs {
+		for name, nd := range d.Nodes {
+			if _, ok := flow.Nodes[name]; ok {
+				return nil, files, fmt.Errorf("%s: node '%s' defined twice",
+					files[i], name)
+			}
+			n, err := compileNode(nd, vars)
+			if err != nil {
+				return nil, files, fmt.Errorf("%s: node '%s': %v", files[i], name, err)
+			}
+			flow.Nodes[name] = n
+		}
+	}
+	if err := flow.Validate(); err != nil {
+		return nil, files, fmt.Errorf("%s: %v", path, err)
+	}
+	return flow, files, nil
+}
+
+// readDefs reads the definition at path after the definitions it includes.
+// A file included more than once, e.g. by two files both included, is only
+// read the first time.
+func readDefs(path string, visiting, loaded map[string]bool, defs *[]*flowDef,
+	files *[]string) error {
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if visiting[abs] {
+		return fmt.Errorf("%s: include cycle", path)
+	}
+	if loaded[abs] {
+		return nil
+	}
+	visiting[abs] = true
+	defer delete(visiting, abs)
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if !strings.HasSuffix(path, ".json") {
+		if data, err = yamlToJSON(data); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	d := &flowDef{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(d); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	for _, inc := range d.Include {
+		if !filepath.IsAbs(inc) {
+			inc = filepath.Join(filepath.Dir(path), inc)
+		}
+		if err := readDefs(inc, visiting, loaded, defs, files); err != nil {
+			return err
+		}
+	}
+	loaded[abs] = true
+	*defs = append(*defs, d)
+	*files = append(*files, path)
+	return nil
+}
+
+// yamlToJSON converts a YAML document to JSON so both formats share a
+// decoder
+func yamlToJSON(data []byte) ([]byte, error) {
+	var v interface{}
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	v, err := jsonValue(v)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// jsonValue replaces the interface keyed maps of the YAML decoder with
+// string keyed maps
+func jsonValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			ks, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("non string key: '%v'", k)
+			}
+			e, err := jsonValue(e)
+			if err != nil {
+				return nil, err
+			}
+			m[ks] = e
+		}
+		return m, nil
+	case []interface{}:
+		for i, e := range v {
+			e, err := jsonValue(e)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = e
+		}
+	}
+	return v, nil
+}
+
+func compileNode(nd nodeDef, vars map[string]string) (*Node, error) {
+	n := &Node{
+		Conditions: nd.When,
+		Retries:    nd.Retries,
+		Fallback:   nd.Fallback,
+		Next:       nd.Next,
+	}
+	for i, c := range n.Conditions {
+		v, err := expand(c.Value, vars)
+		if err != nil {
+			return nil, err
+		}
+		n.Conditions[i].Value = v
+	}
+	for _, b := range nd.Branches {
+		speech := make([]string, len(b.Speech))
+		for i, s := range b.Speech {
+			var err error
+			if speech[i], err = expand(s, vars); err != nil {
+				return nil, err
+			}
+		}
+		n.Branches = append(n.Branches,
+			Branch{Digits: string(b.Digits), Speech: speech, Next: b.Next})
+	}
+
+	var err error
+	if len(nd.Gather) > 0 {
+		n.Input = &twiml.Gather{}
+		if err := decodeAttrs(nd.Gather, n.Input, vars); err != nil {
+			return nil, fmt.Errorf("gather: %v", err)
+		}
+		if n.Input.Nested != nil {
+			return nil, fmt.Errorf("gather: nested verbs belong in prompt")
+		}
+	}
+	if n.Prompt, err = compileVerbs(nd.Prompt, vars); err != nil {
+		return nil, err
+	}
+	if n.NoInputPrompt, err = compileVerbs(nd.NoInputPrompt, vars); err != nil {
+		return nil, err
+	}
+	if n.InvalidPrompt, err = compileVerbs(nd.InvalidPrompt, vars); err != nil {
+		return nil, err
+	}
+	if n.Actions, err = compileVerbs(nd.Actions, vars); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func compileVerbs(raws []json.RawMessage, vars map[string]string) ([]interface{}, error) {
+	var vs []interface{}
+	for _, raw := range raws {
+		v, err := compileVerb(raw, vars)
+		if err != nil {
+			return nil, err
+		}
+		vs = append(vs, v)
+	}
+	return vs, nil
+}
+
+// compileVerb decodes a verb written as "name" or {name: attributes} into
+// its vocabulary struct value
+func compileVerb(raw json.RawMessage, vars map[string]string) (interface{}, error) {
+	var name string
+	attrs := json.RawMessage("{}")
+	if err := json.Unmarshal(raw, &name); err != nil {
+		var m map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &m); err != nil || len(m) != 1 {
+			return nil, fmt.Errorf("non valid verb: %s", raw)
+		}
+		for k, v := range m {
+			name, attrs = k, v
+		}
+	}
+
+	name = strings.ToLower(name)
+	newVerb, ok := verbs[name]
+	if !ok {
+		return nil, fmt.Errorf("non valid verb: '%s'", name)
+	}
+	v := newVerb()
+
+	// a string sets the verb's text
+	var text string
+	if err := json.Unmarshal(attrs, &text); err == nil {
+		field, ok := textFields[name]
+		if !ok {
+			return nil, fmt.Errorf("%s: takes no text", name)
+		}
+		attrs, _ = json.Marshal(map[string]string{field: text})
+	}
+
+	// nested nouns and verbs are compiled separately
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(attrs, &m); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	var nested []interface{}
+	if raw, ok := m["nested"]; ok {
+		var raws []json.RawMessage
+		if err := json.Unmarshal(raw, &raws); err != nil {
+			return nil, fmt.Errorf("%s: nested: %v", name, err)
+		}
+		var err error
+		if nested, err = compileVerbs(raws, vars); err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		delete(m, "nested")
+		attrs, _ = json.Marshal(m)
+	}
+	if err := decodeAttrs(attrs, v, vars); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+
+	// dereference, the vocabulary is used by value
+	verb := reflect.ValueOf(v).Elem().Interface()
+	if nested == nil {
+		return verb, nil
+	}
+	// the nested elements of Say are SSML, not verbs
+	if _, ok := verb.(twiml.Say); !ok {
+		if verb, ok = twiml.WithNested(verb, nested); ok {
+			return verb, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: takes no nested verbs", name)
+}
+
+// decodeAttrs decodes verb attributes into the vocabulary struct v after
+// expanding variables in them
+func decodeAttrs(attrs json.RawMessage, v interface{}, vars map[string]string) error {
+	expanded, err := expandJSON(string(attrs), vars)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(strings.NewReader(expanded))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+var varRef = regexp.MustCompile(`\$\{([A-Za-z0-9_.-]+)\}`)
+
+// expand replaces ${name} references with the value of var name
+func expand(s string, vars map[string]string) (string, error) {
+	return replaceVars(s, vars, func(v string) string { return v })
+}
+
+// expandJSON replaces ${name} references in a JSON document with the value
+// of var name, escaped to fit in a JSON string
+func expandJSON(s string, vars map[string]string) (string, error) {
+	return replaceVars(s, vars, func(v string) string {
+		b, _ := json.Marshal(v)
+		return string(b[1 : len(b)-1])
+	})
+}
+
+func replaceVars(s string, vars map[string]string,
+	quote func(string) string) (string, error) {
+
+	var err error
+	s = varRef.ReplaceAllStringFunc(s, func(ref string) string {
+		name := varRef.FindStringSubmatch(ref)[1]
+		v, ok := vars[name]
+		if !ok {
+			err = fmt.Errorf("undefined variable: '%s'", name)
+			return ref
+		}
+		return quote(v)
+	})
+	return s, err
+}
+
+// Watch polls the flow definition at path and the files it includes every
+// interval, and loads the flow into h when one of them changes. A definition
+// that fails to load is reported to onError, if set, and h keeps serving the
+// previous flow. Watch returns a function stopping the polling.
+func Watch(h *Handler, path string, interval time.Duration,
+	onError func(error)) (stop func()) {
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var files []string
+		modTimes := map[string]time.Time{}
+		changed := func() bool {
+			c := len(files) == 0
+			for _, f := range files {
+				fi, err := os.Stat(f)
+				if err != nil || !fi.ModTime().Equal(modTimes[f]) {
+					c = true
+				}
+			}
+			return c
+		}
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			if !changed() {
+				continue
+			}
+
+			flow, loaded, err := loadFlow(path)
+			// keep watching path even if it could not be read
+			files = append([]string{path}, loaded...)
+			for _, f := range files {
+				if fi, err := os.Stat(f); err == nil {
+					modTimes[f] = fi.ModTime()
+				}
+			}
+			if err == nil {
+				err = h.SetFlow(flow)
+			}
+			if err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,241 @@
+package ivr_test
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tmc/twilio/ivr"
+)
+
+const mainYAML = `
+start: main
+include: [common.yaml]
+vars:
+  company: Acme
+nodes:
+  main:
+    when:
+      - {param: FromCountry, value: CA, next: goodbye}
+    prompt:
+      - say: {text: "Welcome to ${company}", voice: alice}
+    gather: {numDigits: 1}
+    branches:
+      - {digits: 1, next: sales}
+    fallback: goodbye
+  sales:
+    actions:
+      - dial:
+          callerId: "${sales}"
+          nested: [{number: "+15005550001"}, {client: sales}]
+`
+
+const commonJSON = `{"vars": {"sales": "+15005550006"}}`
+
+const commonYAML = `
+include: [common.json]
+nodes:
+  goodbye:
+    actions: [{say: Goodbye}, hangup]
+`
+
+func TestLoadFlow(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"main.yaml":   mainYAML,
+		"common.yaml": commonYAML,
+		"common.json": commonJSON,
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	flow, err := ivr.LoadFlow(filepath.Join(dir, "main.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := ivr.NewHandler(flow, ivr.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		target string
+		form   url.Values
+		want   string
+	}{
+		{"/ivr", url.Values{}, `<Say voice="alice">Welcome to Acme</Say>`},
+		{"/ivr?event=input", url.Values{"Digits": {"1"}}, `<Dial callerId="+15005550006">`},
+		{"/ivr", url.Values{"FromCountry": {"CA"}}, "Goodbye"},
+	}
+	for i, s := range steps {
+		if got := call(t, h, s.target, s.form); !strings.Contains(got, s.want) {
+			t.Errorf("step %d: got %s, want %q", i, got, s.want)
+		}
+	}
+}
+
+func TestLoadFlowErrors(t *testing.T) {
+	dir := t.TempDir()
+	for _, def := range []string{
+		"start: main\nnodes: {main: {prompt: [{say: \"${missing}\"}]}}",
+		"start: main\nnodes: {main: {actions: [{number: \"+15005550001\"}]}}",
+		"start: main\nnodes: {main: {prompt: [{say: {txt: hello}}]}}",
+	} {
+		path := filepath.Join(dir, "flow.yaml")
+		if err := ioutil.WriteFile(path, []byte(def), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ivr.LoadFlow(path); err == nil {
+			t.Errorf("%s: no error", def)
+		}
+	}
+}
+
+func TestLoadFlowIncludes(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a includes b and c, which both include common
+	write("a.yaml", "start: main\ninclude: [b.yaml, c.yaml]\nnodes: {main: {next: b}}")
+	write("b.yaml", "include: [common.yaml]\nnodes: {b: {next: c}}")
+	write("c.yaml", "include: [common.yaml]\nnodes: {c: {next: bye}}")
+	write("common.yaml", "nodes: {bye: {actions: [hangup]}}")
+	flow, err := ivr.LoadFlow(filepath.Join(dir, "a.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(flow.Nodes) != 4 {
+		t.Errorf("nodes %v", flow.Nodes)
+	}
+
+	write("common.yaml", "include: [a.yaml]\nnodes: {bye: {actions: [hangup]}}")
+	if _, err := ivr.LoadFlow(filepath.Join(dir, "a.yaml")); err == nil ||
+		!strings.Contains(err.Error(), "include cycle") {
+		t.Errorf("cycle: %v", err)
+	}
+}
+
+// varsYAML uses a var needing escapes in JSON in a condition, a speech
+// phrase and a Say text
+const varsYAML = `
+start: main
+vars:
+  co: "AT&T"
+nodes:
+  main:
+    when:
+      - {param: Carrier, value: "${co}", next: carrier}
+    prompt: [{say: "Say ${co}"}]
+    gather: {input: speech}
+    branches:
+      - {speech: ["${co}"], next: carrier}
+  carrier:
+    actions: [{say: "Hello ${co} customer"}, hangup]
+`
+
+func TestLoadFlowVars(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flow.yaml")
+	if err := ioutil.WriteFile(path, []byte(varsYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+	flow, err := ivr.LoadFlow(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	main := flow.Nodes["main"]
+	if v := main.Conditions[0].Value; v != "AT&T" {
+		t.Errorf("condition value %q", v)
+	}
+	if s := main.Branches[0].Speech[0]; s != "AT&T" {
+		t.Errorf("speech %q", s)
+	}
+
+	h, err := ivr.NewHandler(flow, ivr.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := call(t, h, "/ivr", url.Values{"Carrier": {"AT&T"}}); !strings.Contains(got, "Hello AT&amp;T customer") {
+		t.Errorf("condition not matched: %s", got)
+	}
+	if got := call(t, h, "/ivr", url.Values{}); !strings.Contains(got, "Say AT&amp;T") {
+		t.Errorf("got %s", got)
+	}
+	if got := call(t, h, "/ivr?event=input", url.Values{"SpeechResult": {"AT&T"}}); !strings.Contains(got, "Hello AT&amp;T customer") {
+		t.Errorf("speech not matched: %s", got)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "flow.yaml")
+	write := func(def string, age time.Duration) {
+		if err := ioutil.WriteFile(path, []byte(def), 0644); err != nil {
+			t.Fatal(err)
+		}
+		// distinct modification times, whatever the file system resolution
+		mtime := time.Now().Add(-age)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	flow := func(text string) string {
+		return "start: main\nnodes: {main: {actions: [{say: " + text + "}]}}"
+	}
+	write(flow("one"), time.Hour)
+
+	f, err := ivr.LoadFlow(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := ivr.NewHandler(f, ivr.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := make(chan error, 10)
+	stop := ivr.Watch(h, path, time.Millisecond, func(err error) { errs <- err })
+	defer stop()
+
+	// waitFor polls the handler until it serves want
+	waitFor := func(want string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			got := call(t, h, "/ivr", url.Values{})
+			if strings.Contains(got, want) {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("still serving %s, want %q", got, want)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	waitFor("one")
+
+	write(flow("two"), 30*time.Minute)
+	waitFor("two")
+
+	// a broken definition is reported and the previous flow kept
+	write("start: main\nnodes: {main: {next: missing}}", 20*time.Minute)
+	select {
+	case err := <-errs:
+		if !strings.Contains(err.Error(), "missing") {
+			t.Errorf("error %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("broken definition not reported")
+	}
+	waitFor("two")
+
+	write(flow("three"), 10*time.Minute)
+	waitFor("three")
+}
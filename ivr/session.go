@@ -0,0 +1,113 @@
+package ivr
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultSessionTTL is how long NewMemoryStore keeps a session after it was
+// last stored, for calls that hung up before their flow finished.
+const DefaultSessionTTL = time.Hour
+
+// Session is the state of a call in a flow.
+type Session struct {
+	CallSid string
+	// Node is the node the caller is in.
+	Node string
+	// Retries counts the repetitions of Node after no or invalid input.
+	Retries int
+	// Input holds the digits or speech the caller entered, by node name.
+	Input map[string]string
+}
+
+// record saves the input the caller entered at node
+func (s *Session) record(node, input string) {
+	if s.Input == nil {
+		s.Input = make(map[string]string)
+	}
+	s.Input[node] = input
+}
+
+// SessionStore keeps sessions keyed by CallSid.
+type SessionStore interface {
+	// Get returns the session of a call, or nil if there is none.
+	Get(callSid string) (*Session, error)
+	Put(s *Session) error
+	Delete(callSid string) error
+}
+
+// MemoryStore is an in-memory SessionStore. Sessions not stored again
+// within the ttl are dropped.
+type MemoryStore struct {
+	ttl time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]storedSession
+	pruned   time.Time
+}
+
+type storedSession struct {
+	Session
+	stored time.Time
+}
+
+// NewMemoryStore creates an empty in-memory session store keeping sessions
+// for DefaultSessionTTL.
+func NewMemoryStore() *MemoryStore {
+	return NewMemoryStoreTTL(DefaultSessionTTL)
+}
+
+// NewMemoryStoreTTL creates an empty in-memory session store keeping each
+// session for ttl after it was last stored.
+func NewMemoryStoreTTL(ttl time.Duration) *MemoryStore {
+	return &MemoryStore{ttl: ttl, sessions: make(map[string]storedSession)}
+}
+
+// Get returns a copy of the session of a call, or nil if there is none.
+func (m *MemoryStore) Get(callSid string) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[callSid]
+	if !ok || time.Since(s.stored) > m.ttl {
+		return nil, nil
+	}
+	c := s.Session
+	c.Input = copyInput(s.Input)
+	return &c, nil
+}
+
+// Put stores a copy of s.
+func (m *MemoryStore) Put(s *Session) error {
+	c := storedSession{Session: *s, stored: time.Now()}
+	c.Input = copyInput(s.Input)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// drop expired sessions at most once per ttl
+	if c.stored.Sub(m.pruned) > m.ttl {
+		for sid, s := range m.sessions {
+			if c.stored.Sub(s.stored) > m.ttl {
+				delete(m.sessions, sid)
+			}
+		}
+		m.pruned = c.stored
+	}
+	m.sessions[s.CallSid] = c
+	return nil
+}
+
+// Delete removes the session of a call.
+func (m *MemoryStore) Delete(callSid string) error {
+	m.mu.Lock()
+	delete(m.sessions, callSid)
+	m.mu.Unlock()
+	return nil
+}
+
+func copyInput(in map[string]string) map[string]string {
+	c := make(map[string]string, len(in))
+	for k, v := range in {
+		c[k] = v
+	}
+	return c
+}
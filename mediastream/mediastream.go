@@ -0,0 +1,371 @@
+// Package mediastream is a server for twilio Media Streams, the websockets
+// twilio streams live call audio to for <Connect><Stream> and
+// <Start><Stream>. Each accepted stream delivers its audio, decoded from
+// mu-law to PCM, and its marks, DTMF digits and end as events on a channel.
+// Audio can be played back into a <Connect><Stream> call with SendAudio.
+package mediastream
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/tmc/twilio"
+)
+
+// Event names of media stream messages
+const (
+	EventConnected = "connected"
+	EventStart     = "start"
+	EventMedia     = "media"
+	EventMark      = "mark"
+	EventDTMF      = "dtmf"
+	EventStop      = "stop"
+	EventClear     = "clear"
+)
+
+// DefaultBuffer is the number of events buffered per stream by a server
+// without Buffer.
+const DefaultBuffer = 100
+
+// DefaultLead is how far ahead of real time audio is sent by a server
+// without Lead.
+const DefaultLead = 100 * time.Millisecond
+
+// DefaultAcceptTimeout is how long a server without AcceptTimeout holds a
+// started stream nobody takes from Streams.
+const DefaultAcceptTimeout = 10 * time.Second
+
+// message is a media stream message as twilio sends it
+type message struct {
+	Event          string        `json:"event"`
+	SequenceNumber string        `json:"sequenceNumber,omitempty"`
+	StreamSid      string        `json:"streamSid,omitempty"`
+	Protocol       string        `json:"protocol,omitempty"`
+	Version        string        `json:"version,omitempty"`
+	Start          *startMessage `json:"start,omitempty"`
+	Media          *mediaMessage `json:"media,omitempty"`
+	Mark           *markMessage  `json:"mark,omitempty"`
+	DTMF           *dtmfMessage  `json:"dtmf,omitempty"`
+	Stop           *stopMessage  `json:"stop,omitempty"`
+}
+
+type startMessage struct {
+	StreamSid        string            `json:"streamSid"`
+	AccountSid       string            `json:"accountSid"`
+	CallSid          string            `json:"callSid"`
+	Tracks           []string          `json:"tracks"`
+	CustomParameters map[string]string `json:"customParameters"`
+	MediaFormat      MediaFormat       `json:"mediaFormat"`
+}
+
+type mediaMessage struct {
+	Track     string `json:"track,omitempty"`
+	Chunk     string `json:"chunk,omitempty"`
+	Timestamp string `json:"timestamp,omitempty"`
+	Payload   string `json:"payload"`
+}
+
+type markMessage struct {
+	Name string `json:"name"`
+}
+
+type dtmfMessage struct {
+	Track string `json:"track"`
+	Digit string `json:"digit"`
+}
+
+type stopMessage struct {
+	AccountSid string `json:"accountSid"`
+	CallSid    string `json:"callSid"`
+}
+
+// MediaFormat describes the audio of a stream.
+type MediaFormat struct {
+	Encoding   string `json:"encoding"`
+	SampleRate int    `json:"sampleRate"`
+	Channels   int    `json:"channels"`
+}
+
+// Frame is a chunk of call audio.
+type Frame struct {
+	// Track is "inbound" for the caller's audio or "outbound" for the audio
+	// played to the caller.
+	Track string
+	// Chunk numbers the frames of the track from 1.
+	Chunk int
+	// Timestamp is the time of the frame since the start of the stream.
+	Timestamp time.Duration
+	// Payload holds the mu-law audio as received, Samples the decoded PCM.
+	Payload []byte
+	Samples []int16
+}
+
+// Event is a message received on a stream after it started.
+type Event struct {
+	// Type is EventMedia, EventMark, EventDTMF or EventStop.
+	Type           string
+	SequenceNumber int
+	// Frame holds the audio of a media event.
+	Frame *Frame
+	// Mark is the name of a mark event, sent when the audio sent before
+	// the mark has been played or cleared.
+	Mark string
+	// Digit is the key pressed in a dtmf event.
+	Digit string
+}
+
+// Stream is an accepted media stream of a call.
+type Stream struct {
+	Sid        string
+	AccountSid string
+	CallSid    string
+	// Tracks lists the tracks streamed, e.g. "inbound".
+	Tracks []string
+	// Parameters holds the Parameter nouns of the Stream.
+	Parameters map[string]string
+	Format     MediaFormat
+	// Events delivers the events of the stream in order. It is closed when
+	// the stream stops or its connection fails, and must be drained for the
+	// stream to make progress.
+	Events <-chan Event
+
+	conn   *websocket.Conn
+	events chan Event
+	lead   time.Duration
+	mu     sync.Mutex // guards writes and the fields below
+	closed bool
+	err    error
+	clears int           // counts Clear calls, interrupting SendAudio
+	marks  []pendingMark // sent marks not yet acknowledged, in order
+}
+
+// Err returns the error that ended the stream once Events is closed, nil if
+// it was stopped or closed.
+func (st *Stream) Err() error {
+	return st.err
+}
+
+// Close closes the connection of the stream. Twilio ends a <Connect><Stream>
+// and continues with the next verb.
+func (st *Stream) Close() error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.closed = true
+	return st.conn.Close()
+}
+
+// Server is an http.Handler accepting media stream websockets. Accepted
+// streams are delivered on Streams.
+type Server struct {
+	// Validator verifies the signature of each websocket handshake.
+	twilio.Validator
+	// Upgrader upgrades requests to websockets.
+	Upgrader websocket.Upgrader
+	// Buffer is the number of events buffered per stream, DefaultBuffer if
+	// zero.
+	Buffer int
+	// Lead is how far ahead of real time audio sent to a stream is
+	// buffered by twilio, DefaultLead if zero.
+	Lead time.Duration
+	// AcceptTimeout is how long a started stream waits to be taken from
+	// Streams before its connection is closed, DefaultAcceptTimeout if zero.
+	AcceptTimeout time.Duration
+
+	once    sync.Once
+	streams chan *Stream
+}
+
+// NewServer creates a server accepting media streams.
+func NewServer() *Server {
+	return &Server{}
+}
+
+// Streams returns the channel accepted streams are delivered on, once their
+// start message has been received. A stream's connection is held until the
+// stream is taken from the channel, and closed if it isn't within
+// AcceptTimeout.
+func (s *Server) Streams() <-chan *Stream {
+	return s.accepted()
+}
+
+// accepted returns the channel of accepted streams, creating it once
+func (s *Server) accepted() chan *Stream {
+	s.once.Do(func() { s.streams = make(chan *Stream) })
+	return s.streams
+}
+
+// ServeHTTP accepts a media stream websocket and reads its messages until
+// the stream stops.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.Verify(w, r) {
+		return
+	}
+	conn, err := s.Upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	st, err := s.accept(conn)
+	if err != nil {
+		return
+	}
+	// the request context isn't canceled once the connection is hijacked,
+	// so a timeout releases streams nobody accepts
+	timeout := s.AcceptTimeout
+	if timeout == 0 {
+		timeout = DefaultAcceptTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case s.accepted() <- st:
+	case <-timer.C:
+		return
+	}
+	st.read()
+}
+
+// accept reads the messages of a new connection up to the start message
+func (s *Server) accept(conn *websocket.Conn) (*Stream, error) {
+	for {
+		var m message
+		if err := conn.ReadJSON(&m); err != nil {
+			return nil, err
+		}
+		switch m.Event {
+		case EventConnected:
+			continue
+		case EventStart:
+			if m.Start == nil {
+				return nil, fmt.Errorf("start message without start")
+			}
+			buffer := s.Buffer
+			if buffer == 0 {
+				buffer = DefaultBuffer
+			}
+			lead := s.Lead
+			if lead == 0 {
+				lead = DefaultLead
+			}
+			events := make(chan Event, buffer)
+			return &Stream{
+				Sid:        m.Start.StreamSid,
+				AccountSid: m.Start.AccountSid,
+				CallSid:    m.Start.CallSid,
+				Tracks:     m.Start.Tracks,
+				Parameters: m.Start.CustomParameters,
+				Format:     m.Start.MediaFormat,
+				Events:     events,
+				conn:       conn,
+				events:     events,
+				lead:       lead,
+			}, nil
+		default:
+			return nil, fmt.Errorf("unexpected '%s' message before start", m.Event)
+		}
+	}
+}
+
+// read delivers the messages of a started stream as events until it stops
+func (st *Stream) read() {
+	defer close(st.events)
+	defer st.ackMarks("")
+	for {
+		_, data, err := st.conn.ReadMessage()
+		if err != nil {
+			st.mu.Lock()
+			closed := st.closed
+			st.mu.Unlock()
+			if !closed && !websocket.IsCloseError(err, websocket.CloseNormalClosure,
+				websocket.CloseGoingAway) {
+				st.err = err
+			}
+			return
+		}
+		var m message
+		if err := json.Unmarshal(data, &m); err != nil {
+			st.err = err
+			return
+		}
+		ev, err := newEvent(&m)
+		if err != nil {
+			st.err = err
+			return
+		}
+		if ev.Type == "" {
+			continue
+		}
+		if ev.Type == EventMark {
+			st.ackMarks(ev.Mark)
+		}
+		st.events <- ev
+		if ev.Type == EventStop {
+			return
+		}
+	}
+}
+
+// newEvent converts a message into an event, with an empty type for messages
+// that are not events
+func newEvent(m *message) (Event, error) {
+	ev := Event{}
+	if m.SequenceNumber != "" {
+		n, err := strconv.Atoi(m.SequenceNumber)
+		if err != nil {
+			return ev, fmt.Errorf("non valid sequenceNumber: '%s'", m.SequenceNumber)
+		}
+		ev.SequenceNumber = n
+	}
+	switch m.Event {
+	default:
+		return ev, nil
+	case EventMedia:
+		if m.Media == nil {
+			return ev, fmt.Errorf("media message without media")
+		}
+		f, err := newFrame(m.Media)
+		if err != nil {
+			return ev, err
+		}
+		ev.Frame = f
+	case EventMark:
+		if m.Mark != nil {
+			ev.Mark = m.Mark.Name
+		}
+	case EventDTMF:
+		if m.DTMF != nil {
+			ev.Digit = m.DTMF.Digit
+		}
+	case EventStop:
+	}
+	ev.Type = m.Event
+	return ev, nil
+}
+
+func newFrame(m *mediaMessage) (*Frame, error) {
+	payload, err := base64.StdEncoding.DecodeString(m.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("non valid media payload: %v", err)
+	}
+	f := &Frame{Track: m.Track, Payload: payload, Samples: DecodeMulaw(payload)}
+	if m.Chunk != "" {
+		if f.Chunk, err = strconv.Atoi(m.Chunk); err != nil {
+			return nil, fmt.Errorf("non valid chunk: '%s'", m.Chunk)
+		}
+	}
+	if m.Timestamp != "" {
+		ms, err := strconv.Atoi(m.Timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("non valid timestamp: '%s'", m.Timestamp)
+		}
+		f.Timestamp = time.Duration(ms) * time.Millisecond
+	}
+	return f, nil
+}
@@ -0,0 +1,194 @@
+package mediastream
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// dial connects a fake twilio to the server
+func dial(t *testing.T, s *Server) (*websocket.Conn, func()) {
+	t.Helper()
+	ts := httptest.NewServer(s)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http"), nil)
+	if err != nil {
+		ts.Close()
+		t.Fatal(err)
+	}
+	return conn, func() {
+		conn.Close()
+		ts.Close()
+	}
+}
+
+func send(t *testing.T, conn *websocket.Conn, msgs ...string) {
+	t.Helper()
+	for _, m := range msgs {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(m)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+const (
+	connectedMsg = `{"event":"connected","protocol":"Call","version":"1.0.0"}`
+	startMsg     = `{"event":"start","sequenceNumber":"1","streamSid":"MZ1","start":{
+		"streamSid":"MZ1","accountSid":"AC1","callSid":"CA1","tracks":["inbound"],
+		"customParameters":{"lang":"en"},
+		"mediaFormat":{"encoding":"audio/x-mulaw","sampleRate":8000,"channels":1}}}`
+)
+
+func TestServer(t *testing.T) {
+	s := NewServer()
+	conn, done := dial(t, s)
+	defer done()
+
+	send(t, conn, connectedMsg, startMsg,
+		`{"event":"media","sequenceNumber":"2","streamSid":"MZ1","media":{
+			"track":"inbound","chunk":"1","timestamp":"20","payload":"/wCA"}}`,
+		`{"event":"dtmf","sequenceNumber":"3","streamSid":"MZ1","dtmf":{"track":"inbound_track","digit":"5"}}`,
+		`{"event":"mark","sequenceNumber":"4","streamSid":"MZ1","mark":{"name":"greeting"}}`,
+		`{"event":"stop","sequenceNumber":"5","streamSid":"MZ1","stop":{"accountSid":"AC1","callSid":"CA1"}}`)
+
+	var st *Stream
+	select {
+	case st = <-s.Streams():
+	case <-time.After(time.Second):
+		t.Fatal("no stream accepted")
+	}
+	if st.CallSid != "CA1" || st.Parameters["lang"] != "en" || st.Format.SampleRate != SampleRate {
+		t.Errorf("stream %+v", st)
+	}
+
+	var events []Event
+	for ev := range st.Events {
+		events = append(events, ev)
+	}
+	if st.Err() != nil {
+		t.Error(st.Err())
+	}
+	var types []string
+	for _, ev := range events {
+		types = append(types, ev.Type)
+	}
+	if want := []string{EventMedia, EventDTMF, EventMark, EventStop}; !reflect.DeepEqual(types, want) {
+		t.Fatalf("events %v, want %v", types, want)
+	}
+	f := events[0].Frame
+	if f.Chunk != 1 || f.Timestamp != 20*time.Millisecond ||
+		!reflect.DeepEqual(f.Samples, []int16{0, -32124, 32124}) {
+		t.Errorf("frame %+v", f)
+	}
+	if events[1].Digit != "5" || events[2].Mark != "greeting" || events[3].SequenceNumber != 5 {
+		t.Errorf("events %+v", events)
+	}
+}
+
+func TestServerRejectsMediaBeforeStart(t *testing.T) {
+	s := NewServer()
+	conn, done := dial(t, s)
+	defer done()
+
+	send(t, conn, `{"event":"media","media":{"payload":""}}`)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, _, err := conn.ReadMessage(); err == nil {
+		t.Error("connection kept open")
+	}
+}
+
+func TestServerAcceptTimeout(t *testing.T) {
+	s := NewServer()
+	s.AcceptTimeout = 10 * time.Millisecond
+	conn, done := dial(t, s)
+	defer done()
+
+	// nobody takes the stream from Streams
+	send(t, conn, connectedMsg, startMsg)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, _, err := conn.ReadMessage()
+	if _, ok := err.(*websocket.CloseError); !ok && !strings.Contains(fmt.Sprint(err), "EOF") {
+		t.Errorf("connection not closed: %v", err)
+	}
+}
+
+func TestMulawRoundTrip(t *testing.T) {
+	for i := 0; i < 256; i++ {
+		if i == 0x7f {
+			continue // negative zero encodes as zero
+		}
+		if u := EncodeMulaw(DecodeMulaw([]byte{byte(i)}))[0]; u != byte(i) {
+			t.Errorf("%#x encoded back as %#x", i, u)
+		}
+	}
+}
+
+// readMessage reads an outbound message on the fake twilio side
+func readMessage(t *testing.T, conn *websocket.Conn) message {
+	t.Helper()
+	var m message
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if err := conn.ReadJSON(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestSendAudio(t *testing.T) {
+	s := &Server{Lead: time.Millisecond}
+	conn, done := dial(t, s)
+	defer done()
+	send(t, conn, connectedMsg, startMsg)
+	st := <-s.Streams()
+	go func() {
+		for range st.Events {
+		}
+	}()
+
+	start := time.Now()
+	if err := st.SendAudio(context.Background(), make([]int16, 2*frameSize+10)); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 2*FrameDuration-time.Millisecond {
+		t.Errorf("3 frames sent in %v", elapsed)
+	}
+	acked, err := st.Mark("end")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sizes []int
+	for i := 0; i < 3; i++ {
+		m := readMessage(t, conn)
+		payload, _ := base64.StdEncoding.DecodeString(m.Media.Payload)
+		sizes = append(sizes, len(payload))
+	}
+	if !reflect.DeepEqual(sizes, []int{frameSize, frameSize, 10}) {
+		t.Errorf("frame sizes %v", sizes)
+	}
+	if m := readMessage(t, conn); m.Event != EventMark || m.Mark.Name != "end" || m.StreamSid != "MZ1" {
+		t.Errorf("mark message %+v", m)
+	}
+	if pending := st.PendingMarks(); !reflect.DeepEqual(pending, []string{"end"}) {
+		t.Errorf("pending marks %v", pending)
+	}
+
+	if err := st.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if m := readMessage(t, conn); m.Event != EventClear {
+		t.Errorf("clear message %+v", m)
+	}
+	send(t, conn, `{"event":"mark","sequenceNumber":"2","streamSid":"MZ1","mark":{"name":"end"}}`)
+	select {
+	case <-acked:
+	case <-time.After(time.Second):
+		t.Fatal("mark not acknowledged")
+	}
+}
@@ -0,0 +1,66 @@
+package mediastream
+
+// SampleRate is the sample rate of twilio media stream audio.
+const SampleRate = 8000
+
+// Encoding is the media format encoding of twilio media stream audio,
+// 8 bit G.711 mu-law.
+const Encoding = "audio/x-mulaw"
+
+// mulawTable maps mu-law bytes to 16 bit linear PCM samples
+var mulawTable [256]int16
+
+func init() {
+	for i := range mulawTable {
+		u := ^byte(i)
+		exponent := (u >> 4) & 0x07
+		mantissa := int16(u & 0x0f)
+		sample := (mantissa<<3+0x84)<<exponent - 0x84
+		if u&0x80 != 0 {
+			sample = -sample
+		}
+		mulawTable[i] = sample
+	}
+}
+
+// DecodeMulaw decodes mu-law audio to 16 bit linear PCM samples.
+func DecodeMulaw(mulaw []byte) []int16 {
+	pcm := make([]int16, len(mulaw))
+	for i, u := range mulaw {
+		pcm[i] = mulawTable[u]
+	}
+	return pcm
+}
+
+// mulawBias and mulawClip are the bias added to and the largest magnitude of
+// samples encoded to mu-law
+const (
+	mulawBias = 0x84
+	mulawClip = 32635
+)
+
+// EncodeMulaw encodes 16 bit linear PCM samples to mu-law audio.
+func EncodeMulaw(pcm []int16) []byte {
+	mulaw := make([]byte, len(pcm))
+	for i, s := range pcm {
+		mulaw[i] = encodeMulaw(s)
+	}
+	return mulaw
+}
+
+func encodeMulaw(s int16) byte {
+	x, sign := int(s), byte(0)
+	if x < 0 {
+		x, sign = -x, 0x80
+	}
+	if x > mulawClip {
+		x = mulawClip
+	}
+	x += mulawBias
+	exponent := 7
+	for mask := 0x4000; x&mask == 0 && exponent > 0; mask >>= 1 {
+		exponent--
+	}
+	mantissa := (x >> uint(exponent+3)) & 0x0f
+	return ^(sign | byte(exponent<<4) | byte(mantissa))
+}
@@ -0,0 +1,127 @@
+package mediastream
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"time"
+)
+
+// FrameDuration is the length of the frames audio is sent in.
+const FrameDuration = 20 * time.Millisecond
+
+// frameSize is the number of mu-law bytes in a frame
+const frameSize = SampleRate * int(FrameDuration/time.Millisecond) / 1000
+
+// ErrCleared is returned by SendAudio when Clear interrupts it.
+var ErrCleared = errors.New("mediastream: audio cleared")
+
+// pendingMark is a sent mark waiting for twilio to acknowledge it
+type pendingMark struct {
+	name  string
+	acked chan struct{}
+}
+
+// SendAudio plays PCM audio at SampleRate to the caller of a
+// <Connect><Stream>. The audio is encoded to mu-law and sent in frames of
+// FrameDuration, paced at real-time rate so that no more than the server's
+// Lead is buffered by twilio. It returns when the last frame is sent, with
+// ErrCleared if Clear is called meanwhile, or with the error of ctx.
+func (st *Stream) SendAudio(ctx context.Context, pcm []int16) error {
+	return st.SendMulaw(ctx, EncodeMulaw(pcm))
+}
+
+// SendMulaw is SendAudio for audio already encoded to mu-law.
+func (st *Stream) SendMulaw(ctx context.Context, mulaw []byte) error {
+	st.mu.Lock()
+	clears := st.clears
+	st.mu.Unlock()
+
+	start := time.Now()
+	for i := 0; len(mulaw) > 0; i++ {
+		due := start.Add(time.Duration(i)*FrameDuration - st.lead)
+		if wait := time.Until(due); wait > 0 {
+			t := time.NewTimer(wait)
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				t.Stop()
+				return ctx.Err()
+			}
+		}
+		n := frameSize
+		if n > len(mulaw) {
+			n = len(mulaw)
+		}
+		st.mu.Lock()
+		if st.clears != clears {
+			st.mu.Unlock()
+			return ErrCleared
+		}
+		err := st.conn.WriteJSON(&message{Event: EventMedia, StreamSid: st.Sid,
+			Media: &mediaMessage{Payload: base64.StdEncoding.EncodeToString(mulaw[:n])}})
+		st.mu.Unlock()
+		if err != nil {
+			return err
+		}
+		mulaw = mulaw[n:]
+	}
+	return nil
+}
+
+// Mark sends a mark after the audio sent so far. The returned channel is
+// closed when twilio acknowledges the mark, once the audio before it has been
+// played or cleared, or when the stream ends.
+func (st *Stream) Mark(name string) (<-chan struct{}, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if err := st.conn.WriteJSON(&message{Event: EventMark, StreamSid: st.Sid,
+		Mark: &markMessage{Name: name}}); err != nil {
+		return nil, err
+	}
+	m := pendingMark{name: name, acked: make(chan struct{})}
+	st.marks = append(st.marks, m)
+	return m.acked, nil
+}
+
+// PendingMarks returns the names of the sent marks twilio has not
+// acknowledged yet, in order.
+func (st *Stream) PendingMarks() []string {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	names := make([]string, len(st.marks))
+	for i, m := range st.marks {
+		names[i] = m.name
+	}
+	return names
+}
+
+// Clear discards the audio buffered by twilio and interrupts SendAudio, e.g.
+// when the caller barges in. Twilio acknowledges the pending marks.
+func (st *Stream) Clear() error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.clears++
+	return st.conn.WriteJSON(&message{Event: EventClear, StreamSid: st.Sid})
+}
+
+// ackMarks acknowledges the first pending mark named name, or all pending
+// marks if name is empty
+func (st *Stream) ackMarks(name string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for i, m := range st.marks {
+		if name == "" {
+			close(m.acked)
+			continue
+		}
+		if m.name == name {
+			close(m.acked)
+			st.marks = append(st.marks[:i], st.marks[i+1:]...)
+			return
+		}
+	}
+	if name == "" {
+		st.marks = nil
+	}
+}
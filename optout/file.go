@@ -0,0 +1,97 @@
+package optout
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FileStore is a Store kept in memory and persisted to a file holding one
+// opted out number per line. The file is rewritten on every change.
+type FileStore struct {
+	path string
+
+	mu  sync.Mutex // serializes changes and file writes
+	mem *MemoryStore
+}
+
+// OpenFileStore loads the store at path. A missing file is treated as an
+// empty store and created on the first change.
+func OpenFileStore(path string) (*FileStore, error) {
+	s := &FileStore{path: path, mem: NewMemoryStore()}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if number := strings.TrimSpace(scanner.Text()); number != "" {
+			s.mem.OptOut(number)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// OptOut records that number opted out and saves the file.
+func (s *FileStore) OptOut(number string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if out, _ := s.mem.OptedOut(number); out {
+		return nil
+	}
+	s.mem.OptOut(number)
+	return s.save()
+}
+
+// OptIn records that number opted back in and saves the file.
+func (s *FileStore) OptIn(number string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if out, _ := s.mem.OptedOut(number); !out {
+		return nil
+	}
+	s.mem.OptIn(number)
+	return s.save()
+}
+
+// OptedOut reports whether number has opted out.
+func (s *FileStore) OptedOut(number string) (bool, error) {
+	return s.mem.OptedOut(number)
+}
+
+// save atomically replaces the file with the current opt-out list
+func (s *FileStore) save() error {
+	numbers := s.mem.Numbers()
+	sort.Strings(numbers)
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(tmp)
+	for _, n := range numbers {
+		w.WriteString(n + "\n")
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
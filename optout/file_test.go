@@ -0,0 +1,35 @@
+package optout_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/tmc/twilio/optout"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "optouts")
+
+	s, err := optout.OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []string{"+15005550001", "+15005550002"} {
+		if err := s.OptOut(n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.OptIn("+15005550002"); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = optout.OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n, want := range map[string]bool{"+15005550001": true, "+15005550002": false} {
+		if out, _ := s.OptedOut(n); out != want {
+			t.Errorf("OptedOut(%s) = %v, want %v", n, out, want)
+		}
+	}
+}
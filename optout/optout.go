@@ -0,0 +1,101 @@
+// Package optout keeps track of phone numbers that opted out of receiving
+// messages, so that sends to them can be blocked before twilio rejects them.
+package optout
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrorCode is the twilio error code returned when sending a message to a
+// number that opted out.
+const ErrorCode = 21610
+
+// Store records the opt-out state of phone numbers.
+type Store interface {
+	// OptOut records that number opted out.
+	OptOut(number string) error
+	// OptIn records that number opted back in.
+	OptIn(number string) error
+	// OptedOut reports whether number has opted out.
+	OptedOut(number string) (bool, error)
+}
+
+// OptedOutError is returned when a message is not sent because the recipient
+// opted out.
+type OptedOutError struct {
+	Number string
+}
+
+func (e *OptedOutError) Error() string {
+	return fmt.Sprintf("recipient opted out: '%s'", e.Number)
+}
+
+// Normalize returns number in E.164 form, so differently formatted numbers
+// are recorded once: spaces, dashes, dots and parentheses are removed and an
+// international 00 prefix is replaced by "+". A channel prefix such as
+// "whatsapp:" is kept. Numbers without a country code are left as they are.
+func Normalize(number string) string {
+	channel := ""
+	if i := strings.LastIndexByte(number, ':'); i >= 0 {
+		channel, number = number[:i+1], number[i+1:]
+	}
+	number = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, number)
+	if strings.HasPrefix(number, "00") {
+		number = "+" + number[2:]
+	}
+	return channel + number
+}
+
+// MemoryStore is an in-memory Store. Numbers are normalized before they are
+// recorded or looked up.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	numbers map[string]bool
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{numbers: make(map[string]bool)}
+}
+
+// OptOut records that number opted out.
+func (m *MemoryStore) OptOut(number string) error {
+	m.mu.Lock()
+	m.numbers[Normalize(number)] = true
+	m.mu.Unlock()
+	return nil
+}
+
+// OptIn records that number opted back in.
+func (m *MemoryStore) OptIn(number string) error {
+	m.mu.Lock()
+	delete(m.numbers, Normalize(number))
+	m.mu.Unlock()
+	return nil
+}
+
+// OptedOut reports whether number has opted out.
+func (m *MemoryStore) OptedOut(number string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.numbers[Normalize(number)], nil
+}
+
+// Numbers returns the numbers that opted out, in no particular order.
+func (m *MemoryStore) Numbers() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	numbers := make([]string, 0, len(m.numbers))
+	for n := range m.numbers {
+		numbers = append(numbers, n)
+	}
+	return numbers
+}
@@ -0,0 +1,38 @@
+package optout_test
+
+import (
+	"testing"
+
+	"github.com/tmc/twilio/optout"
+)
+
+func TestNormalize(t *testing.T) {
+	for number, want := range map[string]string{
+		"+15005550001":             "+15005550001",
+		"+1 500 555 0001":          "+15005550001",
+		"+1 (500) 555-0001":        "+15005550001",
+		"+1.500.555.0001":          "+15005550001",
+		"0015005550001":            "+15005550001",
+		"whatsapp:+1 500 555 0001": "whatsapp:+15005550001",
+		"12345":                    "12345",
+	} {
+		if got := optout.Normalize(number); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", number, got, want)
+		}
+	}
+}
+
+func TestMemoryStoreNormalizes(t *testing.T) {
+	s := optout.NewMemoryStore()
+	s.OptOut("+1 500 555-0001")
+	if out, _ := s.OptedOut("+15005550001"); !out {
+		t.Error("formatted number not matched")
+	}
+	if n := s.Numbers(); len(n) != 1 || n[0] != "+15005550001" {
+		t.Errorf("numbers %v", n)
+	}
+	s.OptIn("+1 (500) 555 0001")
+	if out, _ := s.OptedOut("+15005550001"); out {
+		t.Error("opt in of formatted number not recorded")
+	}
+}
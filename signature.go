@@ -0,0 +1,71 @@
+package twilio
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"sort"
+)
+
+// SignatureHeader is the http header carrying the signature twilio adds to
+// each webhook request.
+const SignatureHeader = "X-Twilio-Signature"
+
+// Signature computes the signature twilio sends for a request to the given
+// url with the given POST parameters.
+// See https://www.twilio.com/docs/usage/security#validating-requests for
+// details.
+func Signature(authToken, url string, params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(url))
+	for _, k := range keys {
+		values := append([]string(nil), params[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			mac.Write([]byte(k + v))
+		}
+	}
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ValidateSignature reports whether signature is the signature twilio
+// computes for a request to url with params.
+func ValidateSignature(authToken, url string, params url.Values, signature string) bool {
+	expected := Signature(authToken, url, params)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// ValidateRequest reports whether r carries a valid twilio signature. The
+// signed url is the one twilio requested: baseURL (scheme and host, e.g.
+// "https://example.com") followed by the request URI, as received even when
+// the handler is mounted under http.StripPrefix. If baseURL is empty it is
+// derived from the request, honouring X-Forwarded-Proto.
+func ValidateRequest(authToken, baseURL string, r *http.Request) bool {
+	if err := r.ParseForm(); err != nil {
+		return false
+	}
+	if baseURL == "" {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+			scheme = proto
+		}
+		baseURL = scheme + "://" + r.Host
+	}
+	uri := r.URL.RequestURI()
+	if u, err := url.ParseRequestURI(r.RequestURI); err == nil && u.Path != "" {
+		uri = u.RequestURI()
+	}
+	return ValidateSignature(authToken, baseURL+uri, r.PostForm,
+		r.Header.Get(SignatureHeader))
+}
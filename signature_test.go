@@ -0,0 +1,43 @@
+package twilio_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/tmc/twilio"
+)
+
+func ExampleSignature() {
+	params := url.Values{
+		"CallSid": {"CA1234567890ABCDE"},
+		"Caller":  {"+12349013030"},
+		"Digits":  {"1234"},
+		"From":    {"+12349013030"},
+		"To":      {"+18005551212"},
+	}
+	sig := twilio.Signature("12345", "https://mycompany.com/myapp.php?foo=1&bar=2", params)
+	fmt.Println(sig)
+	// output:
+	// 0/KCTR6DLpKmkAf8muzZqo1nDgQ=
+}
+
+func TestValidateRequestStripPrefix(t *testing.T) {
+	form := url.Values{"CallSid": {"CA1234567890ABCDE"}}
+	r := httptest.NewRequest("POST", "https://example.com/voice/ivr?x=1", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set(twilio.SignatureHeader,
+		twilio.Signature("secret", "https://example.com/voice/ivr?x=1", form))
+
+	valid := false
+	h := http.StripPrefix("/voice", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		valid = twilio.ValidateRequest("secret", "https://example.com", r)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if !valid {
+		t.Error("signature of a request under StripPrefix not valid")
+	}
+}
@@ -0,0 +1,73 @@
+package sms
+
+import (
+	"strings"
+
+	"github.com/tmc/twilio/twiml"
+)
+
+// Keywords carriers require to opt a number out of, back into, and to get
+// help about messages.
+var (
+	StopKeywords  = []string{"STOP", "STOPALL", "UNSUBSCRIBE", "CANCEL", "END", "QUIT"}
+	StartKeywords = []string{"START", "YES", "UNSTOP"}
+	HelpKeywords  = []string{"HELP", "INFO"}
+)
+
+// Default replies of the keyword handlers installed by NewRouter.
+var (
+	StopReply = "You have successfully been unsubscribed. You will not " +
+		"receive any more messages from this number. Reply START to resubscribe."
+	StartReply = "You have successfully been re-subscribed to messages from " +
+		"this number. Reply HELP for help. Reply STOP to unsubscribe."
+	HelpReply = "Reply STOP to unsubscribe. Msg&Data Rates May Apply."
+)
+
+// OptOuts records the numbers that opted out of receiving messages. It is
+// satisfied by every optout.Store.
+type OptOuts interface {
+	OptOut(number string) error
+	OptIn(number string) error
+}
+
+// recordOptOut records the sender's opt-out state if the message is an
+// opt-out or opt-in keyword. Twilio's own keyword detection (OptOutType) is
+// trusted when present.
+func (rt *Router) recordOptOut(c *Context) error {
+	if rt.OptOuts == nil {
+		return nil
+	}
+	switch optOutType(c) {
+	case twiml.TwiOptOutStop:
+		return rt.OptOuts.OptOut(c.From)
+	case twiml.TwiOptOutStart:
+		return rt.OptOuts.OptIn(c.From)
+	}
+	return nil
+}
+
+// optOutType classifies the message as one of the opt-out types
+func optOutType(c *Context) string {
+	if c.OptOutType != "" {
+		return strings.ToUpper(c.OptOutType)
+	}
+	text := strings.ToUpper(c.Text)
+	switch {
+	case contains(StopKeywords, text):
+		return twiml.TwiOptOutStop
+	case contains(StartKeywords, text):
+		return twiml.TwiOptOutStart
+	case contains(HelpKeywords, text):
+		return twiml.TwiOptOutHelp
+	}
+	return ""
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
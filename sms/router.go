@@ -0,0 +1,194 @@
+// Package sms routes incoming text messages to handlers by keyword, with
+// carrier compliant STOP/START/HELP handling built in.
+package sms
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/tmc/twilio"
+	"github.com/tmc/twilio/optout"
+	"github.com/tmc/twilio/twiml"
+)
+
+// Context is passed to a Handler for each incoming message.
+type Context struct {
+	*twiml.MessageRequest
+	Request *http.Request
+	// Text is the message body with surrounding whitespace trimmed and
+	// inner whitespace collapsed to single spaces.
+	Text string
+	// Keyword is the upper cased keyword or prefix that matched, empty for
+	// regexp and fallback routes.
+	Keyword string
+	// Args is the text following a matched keyword or prefix.
+	Args string
+	// Match holds the submatches of a matched regexp.
+	Match []string
+}
+
+// Handler answers an incoming message. A nil response replies with an empty
+// TwiML document, i.e. no message is sent back.
+type Handler func(*Context) *twiml.MessagingResponse
+
+// Reply returns a handler answering every message with text.
+func Reply(text string) Handler {
+	return func(*Context) *twiml.MessagingResponse {
+		resp := twiml.NewMessagingResponse()
+		resp.Action(twiml.Message{Body: text})
+		return resp
+	}
+}
+
+type prefixRoute struct {
+	prefix  string
+	handler Handler
+}
+
+type regexpRoute struct {
+	re      *regexp.Regexp
+	handler Handler
+}
+
+// Router is an http.Handler for incoming messages. Exact keywords are tried
+// first, then prefixes and regexps in the order they were added, then
+// NotFound. Matching ignores case and extra whitespace.
+type Router struct {
+	// Validator verifies the signature of each request.
+	twilio.Validator
+	// OptOuts records numbers texting opt-out and opt-in keywords. Opt-out
+	// state is not recorded if nil.
+	OptOuts OptOuts
+	// NotFound handles messages no route matches. No reply is sent if nil.
+	NotFound Handler
+
+	keywords map[string]Handler
+	prefixes []prefixRoute
+	regexps  []regexpRoute
+}
+
+// NewRouter creates a router with the default STOP, START and HELP keyword
+// handlers and an in-memory opt-out record.
+func NewRouter() *Router {
+	rt := &Router{
+		OptOuts:  optout.NewMemoryStore(),
+		keywords: make(map[string]Handler),
+	}
+	for _, kw := range StopKeywords {
+		rt.Keyword(kw, Reply(StopReply))
+	}
+	for _, kw := range StartKeywords {
+		rt.Keyword(kw, Reply(StartReply))
+	}
+	for _, kw := range HelpKeywords {
+		rt.Keyword(kw, Reply(HelpReply))
+	}
+	return rt
+}
+
+// Keyword routes messages consisting of exactly keyword to h, replacing any
+// handler previously added for the keyword.
+func (rt *Router) Keyword(keyword string, h Handler) {
+	if rt.keywords == nil {
+		rt.keywords = make(map[string]Handler)
+	}
+	rt.keywords[normalize(keyword)] = h
+}
+
+// Prefix routes messages starting with the word prefix to h. The rest of the
+// message is passed in Context.Args.
+func (rt *Router) Prefix(prefix string, h Handler) {
+	rt.prefixes = append(rt.prefixes, prefixRoute{normalize(prefix), h})
+}
+
+// Regexp routes messages matching the regular expression expr to h. The
+// expression is matched case insensitively against the normalized text.
+func (rt *Router) Regexp(expr string, h Handler) error {
+	re, err := regexp.Compile("(?i)" + expr)
+	if err != nil {
+		return err
+	}
+	rt.regexps = append(rt.regexps, regexpRoute{re, h})
+	return nil
+}
+
+// ServeHTTP parses the incoming message, records opt-out keywords and writes
+// the TwiML response of the matching handler.
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !rt.Verify(w, r) {
+		return
+	}
+	mr, err := twiml.ParseMessageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c := &Context{MessageRequest: mr, Request: r, Text: collapse(mr.Body)}
+	if err := rt.recordOptOut(c); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := rt.route(c)
+	if resp == nil {
+		resp = twiml.NewMessagingResponse()
+	}
+	w.Header().Set("Content-Type", "text/xml")
+	resp.Send(w)
+}
+
+// route finds and calls the handler for the message
+func (rt *Router) route(c *Context) *twiml.MessagingResponse {
+	text := strings.ToUpper(c.Text)
+
+	if h, ok := rt.keywords[text]; ok {
+		c.Keyword = text
+		return h(c)
+	}
+	words := strings.Fields(c.Text)
+	for _, p := range rt.prefixes {
+		if n, ok := hasPrefix(words, p.prefix); ok {
+			c.Keyword = p.prefix
+			c.Args = strings.Join(words[n:], " ")
+			return p.handler(c)
+		}
+	}
+	for _, re := range rt.regexps {
+		if m := re.re.FindStringSubmatch(c.Text); m != nil {
+			c.Match = m
+			return re.handler(c)
+		}
+	}
+	if rt.NotFound != nil {
+		return rt.NotFound(c)
+	}
+	return nil
+}
+
+// hasPrefix reports whether words start with the words of prefix, ignoring
+// case, and returns the number of words matched. Words are compared one by
+// one as upper casing may change their length.
+func hasPrefix(words []string, prefix string) (int, bool) {
+	pw := strings.Fields(prefix)
+	if len(pw) > len(words) {
+		return 0, false
+	}
+	for i, w := range pw {
+		if !strings.EqualFold(words[i], w) {
+			return 0, false
+		}
+	}
+	return len(pw), true
+}
+
+// normalize upper cases s and collapses its whitespace
+func normalize(s string) string {
+	return strings.ToUpper(collapse(s))
+}
+
+// collapse trims s and replaces runs of whitespace with single spaces
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,73 @@
+package sms_test
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/tmc/twilio/optout"
+	"github.com/tmc/twilio/sms"
+	"github.com/tmc/twilio/twiml"
+)
+
+func send(rt *sms.Router, from, body string) string {
+	form := url.Values{
+		"MessageSid": {"SM123"},
+		"AccountSid": {"AC123"},
+		"From":       {from},
+		"Body":       {body},
+	}
+	r := httptest.NewRequest("POST", "/sms", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, r)
+	return w.Body.String()
+}
+
+func TestRouter(t *testing.T) {
+	rt := sms.NewRouter()
+	rt.Prefix("order", func(c *sms.Context) *twiml.MessagingResponse {
+		return sms.Reply("status of " + c.Args)(c)
+	})
+	rt.Prefix("ɐ", func(c *sms.Context) *twiml.MessagingResponse {
+		return sms.Reply("args " + c.Args)(c)
+	})
+	if err := rt.Regexp(`^balance\b`, sms.Reply("balance: 0")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		body string
+		want string
+	}{
+		{"  ORDER   1234 ", "status of 1234"},
+		{"order", "status of <"},
+		{"orders 1", "<Response></Response>"},
+		{"ɐ ɐɐ", "args ɐɐ"},
+		{"ɐɐɐɐɐɐɐɐɐɐ", "<Response></Response>"},
+		{"Balance please", "balance: 0"},
+		{" help", "Reply STOP to unsubscribe."},
+		{"unknown", "<Response></Response>"},
+	}
+	for _, tt := range tests {
+		if got := send(rt, "+15005550001", tt.body); !strings.Contains(got, tt.want) {
+			t.Errorf("%q: got %s, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestStopRecordsOptOut(t *testing.T) {
+	rt := sms.NewRouter()
+	store := optout.NewMemoryStore()
+	rt.OptOuts = store
+
+	send(rt, "+15005550001", "Stop")
+	if out, _ := store.OptedOut("+15005550001"); !out {
+		t.Fatal("STOP not recorded")
+	}
+	send(rt, "+15005550001", "start ")
+	if out, _ := store.OptedOut("+15005550001"); out {
+		t.Fatal("START not recorded")
+	}
+}
@@ -0,0 +1,848 @@
+package twiml
+
+// Builder builds a Response with typed methods. Each verb method returns a
+// builder for the verb's attributes and, for Dial and Gather, for the nouns
+// and verbs that may be nested in it, so illegal nesting does not compile:
+//
+//	b := twiml.NewBuilder()
+//	b.Say("Welcome").Voice(twiml.TwiAlice)
+//	g := b.Gather().NumDigits(1).Action("/menu")
+//	g.Say("Press 1 for sales")
+//	b.Dial().CallerId("+15005550006").Number("+15005550001")
+//	resp := b.Response()
+type Builder struct {
+	verbs []builder
+}
+
+// builder is implemented by the verb and noun builders
+type builder interface {
+	build() interface{}
+}
+
+// NewBuilder creates a builder for an empty response.
+func NewBuilder() *Builder {
+	return new(Builder)
+}
+
+// Response returns the built response holding vocabulary structs as added by
+// Response.Action, Response.Dial and Response.Gather.
+func (b *Builder) Response() *Response {
+	r := NewResponse()
+	r.Response = buildAll(b.verbs)
+	return r
+}
+
+func buildAll(bs []builder) []interface{} {
+	var vs []interface{}
+	for _, b := range bs {
+		vs = append(vs, b.build())
+	}
+	return vs
+}
+
+// Connect appends a Connect verb. Add the stream with the returned builder.
+func (b *Builder) Connect() *ConnectBuilder {
+	c := &ConnectBuilder{}
+	b.verbs = append(b.verbs, c)
+	return c
+}
+
+// Dial appends a Dial verb. Add the dialed party with the returned builder.
+func (b *Builder) Dial() *DialBuilder {
+	d := &DialBuilder{}
+	b.verbs = append(b.verbs, d)
+	return d
+}
+
+// Enqueue appends an Enqueue verb placing the call in the named queue.
+func (b *Builder) Enqueue(name string) *EnqueueBuilder {
+	e := &EnqueueBuilder{v: Enqueue{Name: name}}
+	b.verbs = append(b.verbs, e)
+	return e
+}
+
+// Gather appends a Gather verb. Add its prompts with the returned builder.
+func (b *Builder) Gather() *GatherBuilder {
+	g := &GatherBuilder{}
+	b.verbs = append(b.verbs, g)
+	return g
+}
+
+// Hangup appends a Hangup verb.
+func (b *Builder) Hangup() *Builder {
+	b.verbs = append(b.verbs, valueBuilder{Hangup{}})
+	return b
+}
+
+// Leave appends a Leave verb.
+func (b *Builder) Leave() *Builder {
+	b.verbs = append(b.verbs, valueBuilder{Leave{}})
+	return b
+}
+
+// Message appends a Message verb sending body.
+func (b *Builder) Message(body string) *MessageBuilder {
+	m := &MessageBuilder{v: Message{Body: body}}
+	b.verbs = append(b.verbs, m)
+	return m
+}
+
+// Pause appends a Pause verb of length seconds.
+func (b *Builder) Pause(length int) *Builder {
+	b.verbs = append(b.verbs, valueBuilder{Pause{Length: length}})
+	return b
+}
+
+// Pay appends a Pay verb capturing a payment with the named payment
+// connector.
+func (b *Builder) Pay(connector string) *PayBuilder {
+	p := &PayBuilder{v: Pay{PaymentConnector: connector}}
+	b.verbs = append(b.verbs, p)
+	return p
+}
+
+// Play appends a Play verb playing the audio at url.
+func (b *Builder) Play(url string) *PlayBuilder {
+	p := &PlayBuilder{v: Play{Url: url}}
+	b.verbs = append(b.verbs, p)
+	return p
+}
+
+// Record appends a Record verb.
+func (b *Builder) Record() *RecordBuilder {
+	r := &RecordBuilder{}
+	b.verbs = append(b.verbs, r)
+	return r
+}
+
+// Redirect appends a Redirect verb to url.
+func (b *Builder) Redirect(url string) *RedirectBuilder {
+	r := &RedirectBuilder{v: Redirect{Url: url}}
+	b.verbs = append(b.verbs, r)
+	return r
+}
+
+// Reject appends a Reject verb.
+func (b *Builder) Reject() *RejectBuilder {
+	r := &RejectBuilder{}
+	b.verbs = append(b.verbs, r)
+	return r
+}
+
+// Say appends a Say verb speaking text.
+func (b *Builder) Say(text string) *SayBuilder {
+	s := &SayBuilder{v: Say{Text: text}}
+	b.verbs = append(b.verbs, s)
+	return s
+}
+
+// Start appends a Start verb. Add the stream with the returned builder.
+func (b *Builder) Start() *StartBuilder {
+	s := &StartBuilder{}
+	b.verbs = append(b.verbs, s)
+	return s
+}
+
+// Stop appends a Stop verb. Add the stream to stop with the returned builder.
+func (b *Builder) Stop() *StopBuilder {
+	s := &StopBuilder{}
+	b.verbs = append(b.verbs, s)
+	return s
+}
+
+// valueBuilder builds elements without attributes to set
+type valueBuilder struct {
+	v interface{}
+}
+
+func (b valueBuilder) build() interface{} { return b.v }
+
+// ConnectBuilder sets the attributes and noun of a Connect.
+type ConnectBuilder struct {
+	v     Connect
+	nouns []builder
+}
+
+func (c *ConnectBuilder) build() interface{} {
+	v := c.v
+	v.Nested = buildAll(c.nouns)
+	return v
+}
+
+func (c *ConnectBuilder) Action(url string) *ConnectBuilder    { c.v.Action = url; return c }
+func (c *ConnectBuilder) Method(method string) *ConnectBuilder { c.v.Method = method; return c }
+
+// Stream adds a Stream noun connecting the call to the websocket at url.
+func (c *ConnectBuilder) Stream(url string) *StreamBuilder {
+	s := &StreamBuilder{v: Stream{Url: url}}
+	c.nouns = append(c.nouns, s)
+	return s
+}
+
+// DialBuilder sets the attributes and nouns of a Dial.
+type DialBuilder struct {
+	v     Dial
+	nouns []builder
+}
+
+func (d *DialBuilder) build() interface{} {
+	v := d.v
+	v.Nested = buildAll(d.nouns)
+	return v
+}
+
+func (d *DialBuilder) Action(url string) *DialBuilder    { d.v.Action = url; return d }
+func (d *DialBuilder) Method(method string) *DialBuilder { d.v.Method = method; return d }
+func (d *DialBuilder) Timeout(seconds int) *DialBuilder  { d.v.Timeout = seconds; return d }
+func (d *DialBuilder) HangupOnStar(hangupOnStar bool) *DialBuilder {
+	d.v.HangupOnStar = &hangupOnStar
+	return d
+}
+func (d *DialBuilder) TimeLimit(seconds int) *DialBuilder {
+	d.v.TimeLimit = seconds
+	return d
+}
+func (d *DialBuilder) CallerId(number string) *DialBuilder { d.v.CallerId = number; return d }
+func (d *DialBuilder) Record(record string) *DialBuilder {
+	d.v.Record = record
+	return d
+}
+func (d *DialBuilder) AnswerOnBridge(answerOnBridge bool) *DialBuilder {
+	d.v.AnswerOnBridge = &answerOnBridge
+	return d
+}
+func (d *DialBuilder) RingTone(country string) *DialBuilder {
+	d.v.RingTone = country
+	return d
+}
+func (d *DialBuilder) RecordingStatusCallback(url string) *DialBuilder {
+	d.v.RecordingStatusCallback = url
+	return d
+}
+func (d *DialBuilder) RecordingStatusCallbackMethod(method string) *DialBuilder {
+	d.v.RecordingStatusCallbackMethod = method
+	return d
+}
+func (d *DialBuilder) RecordingStatusCallbackEvent(events string) *DialBuilder {
+	d.v.RecordingStatusCallbackEvent = events
+	return d
+}
+func (d *DialBuilder) RecordingTrack(track string) *DialBuilder {
+	d.v.RecordingTrack = track
+	return d
+}
+func (d *DialBuilder) Trim(trim string) *DialBuilder    { d.v.Trim = trim; return d }
+func (d *DialBuilder) ReferUrl(url string) *DialBuilder { d.v.ReferUrl = url; return d }
+func (d *DialBuilder) ReferMethod(method string) *DialBuilder {
+	d.v.ReferMethod = method
+	return d
+}
+func (d *DialBuilder) Sequential(sequential bool) *DialBuilder {
+	d.v.Sequential = &sequential
+	return d
+}
+
+// Client adds a Client noun dialing the named client.
+func (d *DialBuilder) Client(name string) *ClientBuilder {
+	c := &ClientBuilder{v: Client{Name: name}}
+	d.nouns = append(d.nouns, c)
+	return c
+}
+
+// Conference adds a Conference noun joining the named conference.
+func (d *DialBuilder) Conference(name string) *ConferenceBuilder {
+	c := &ConferenceBuilder{v: Conference{Name: name}}
+	d.nouns = append(d.nouns, c)
+	return c
+}
+
+// Number adds a Number noun dialing number.
+func (d *DialBuilder) Number(number string) *NumberBuilder {
+	n := &NumberBuilder{v: Number{Number: number}}
+	d.nouns = append(d.nouns, n)
+	return n
+}
+
+// Queue adds a Queue noun connecting to the first caller in the named queue.
+func (d *DialBuilder) Queue(name string) *QueueBuilder {
+	q := &QueueBuilder{v: Queue{Name: name}}
+	d.nouns = append(d.nouns, q)
+	return q
+}
+
+// Sip adds a Sip noun dialing the SIP address.
+func (d *DialBuilder) Sip(address string) *SipBuilder {
+	s := &SipBuilder{v: Sip{Address: address}}
+	d.nouns = append(d.nouns, s)
+	return s
+}
+
+// ClientBuilder sets the attributes of a Client.
+type ClientBuilder struct{ v Client }
+
+// build moves the client name to Identity when there are parameters
+func (c *ClientBuilder) build() interface{} {
+	v := c.v
+	if len(v.Parameters) > 0 && v.Identity == "" {
+		v.Identity, v.Name = v.Name, ""
+	}
+	return v
+}
+
+func (c *ClientBuilder) Url(url string) *ClientBuilder       { c.v.Url = url; return c }
+func (c *ClientBuilder) Method(method string) *ClientBuilder { c.v.Method = method; return c }
+func (c *ClientBuilder) StatusCallback(url string) *ClientBuilder {
+	c.v.StatusCallback = url
+	return c
+}
+func (c *ClientBuilder) StatusCallbackEvent(events string) *ClientBuilder {
+	c.v.StatusCallbackEvent = events
+	return c
+}
+func (c *ClientBuilder) StatusCallbackMethod(method string) *ClientBuilder {
+	c.v.StatusCallbackMethod = method
+	return c
+}
+
+// Parameter adds a custom parameter passed on to the client.
+func (c *ClientBuilder) Parameter(name, value string) *ClientBuilder {
+	c.v.Parameters = append(c.v.Parameters, Parameter{Name: name, Value: value})
+	return c
+}
+
+// ConferenceBuilder sets the attributes of a Conference.
+type ConferenceBuilder struct{ v Conference }
+
+func (c *ConferenceBuilder) build() interface{} { return c.v }
+func (c *ConferenceBuilder) Muted(muted bool) *ConferenceBuilder {
+	c.v.Muted = &muted
+	return c
+}
+func (c *ConferenceBuilder) Beep(beep string) *ConferenceBuilder {
+	c.v.Beep = beep
+	return c
+}
+func (c *ConferenceBuilder) StartConferenceOnEnter(start bool) *ConferenceBuilder {
+	c.v.StartConferenceOnEnter = &start
+	return c
+}
+func (c *ConferenceBuilder) EndConferenceOnExit(end bool) *ConferenceBuilder {
+	c.v.EndConferenceOnExit = &end
+	return c
+}
+func (c *ConferenceBuilder) WaitUrl(url string) *ConferenceBuilder {
+	c.v.WaitUrl = url
+	return c
+}
+func (c *ConferenceBuilder) WaitMethod(method string) *ConferenceBuilder {
+	c.v.WaitMethod = method
+	return c
+}
+func (c *ConferenceBuilder) MaxParticipants(n int) *ConferenceBuilder {
+	c.v.MaxParticipants = n
+	return c
+}
+func (c *ConferenceBuilder) StatusCallback(url string) *ConferenceBuilder {
+	c.v.StatusCallback = url
+	return c
+}
+func (c *ConferenceBuilder) StatusCallbackMethod(method string) *ConferenceBuilder {
+	c.v.StatusCallbackMethod = method
+	return c
+}
+func (c *ConferenceBuilder) StatusCallbackEvent(events ...string) *ConferenceBuilder {
+	c.v.StatusCallbackEvent = events
+	return c
+}
+func (c *ConferenceBuilder) Record(record string) *ConferenceBuilder {
+	c.v.Record = record
+	return c
+}
+func (c *ConferenceBuilder) Trim(trim string) *ConferenceBuilder {
+	c.v.Trim = trim
+	return c
+}
+func (c *ConferenceBuilder) ParticipantLabel(label string) *ConferenceBuilder {
+	c.v.ParticipantLabel = label
+	return c
+}
+
+// Coach joins as coach of the participant on the call callSid, heard only by
+// that participant.
+func (c *ConferenceBuilder) Coach(callSid string) *ConferenceBuilder {
+	c.v.Coach = callSid
+	return c
+}
+func (c *ConferenceBuilder) RecordingStatusCallback(url string) *ConferenceBuilder {
+	c.v.RecordingStatusCallback = url
+	return c
+}
+func (c *ConferenceBuilder) RecordingStatusCallbackMethod(method string) *ConferenceBuilder {
+	c.v.RecordingStatusCallbackMethod = method
+	return c
+}
+func (c *ConferenceBuilder) RecordingStatusCallbackEvent(events string) *ConferenceBuilder {
+	c.v.RecordingStatusCallbackEvent = events
+	return c
+}
+func (c *ConferenceBuilder) Region(region string) *ConferenceBuilder {
+	c.v.Region = region
+	return c
+}
+func (c *ConferenceBuilder) JitterBufferSize(size string) *ConferenceBuilder {
+	c.v.JitterBufferSize = size
+	return c
+}
+
+// NumberBuilder sets the attributes of a Number.
+type NumberBuilder struct{ v Number }
+
+func (n *NumberBuilder) build() interface{} { return n.v }
+func (n *NumberBuilder) SendDigits(digits string) *NumberBuilder {
+	n.v.SendDigits = digits
+	return n
+}
+func (n *NumberBuilder) Url(url string) *NumberBuilder       { n.v.Url = url; return n }
+func (n *NumberBuilder) Method(method string) *NumberBuilder { n.v.Method = method; return n }
+func (n *NumberBuilder) StatusCallback(url string) *NumberBuilder {
+	n.v.StatusCallback = url
+	return n
+}
+func (n *NumberBuilder) StatusCallbackEvent(events string) *NumberBuilder {
+	n.v.StatusCallbackEvent = events
+	return n
+}
+func (n *NumberBuilder) StatusCallbackMethod(method string) *NumberBuilder {
+	n.v.StatusCallbackMethod = method
+	return n
+}
+func (n *NumberBuilder) Byoc(trunkSid string) *NumberBuilder { n.v.Byoc = trunkSid; return n }
+
+// QueueBuilder sets the attributes of a Queue.
+type QueueBuilder struct{ v Queue }
+
+func (q *QueueBuilder) build() interface{}                 { return q.v }
+func (q *QueueBuilder) Url(url string) *QueueBuilder       { q.v.Url = url; return q }
+func (q *QueueBuilder) Method(method string) *QueueBuilder { q.v.Method = method; return q }
+
+// SipBuilder sets the attributes of a Sip.
+type SipBuilder struct{ v Sip }
+
+func (s *SipBuilder) build() interface{} { return s.v }
+func (s *SipBuilder) Username(username string) *SipBuilder {
+	s.v.Username = username
+	return s
+}
+func (s *SipBuilder) Password(password string) *SipBuilder {
+	s.v.Password = password
+	return s
+}
+func (s *SipBuilder) Url(url string) *SipBuilder       { s.v.Url = url; return s }
+func (s *SipBuilder) Method(method string) *SipBuilder { s.v.Method = method; return s }
+func (s *SipBuilder) StatusCallback(url string) *SipBuilder {
+	s.v.StatusCallback = url
+	return s
+}
+func (s *SipBuilder) StatusCallbackEvent(events string) *SipBuilder {
+	s.v.StatusCallbackEvent = events
+	return s
+}
+func (s *SipBuilder) StatusCallbackMethod(method string) *SipBuilder {
+	s.v.StatusCallbackMethod = method
+	return s
+}
+
+// EnqueueBuilder sets the attributes of an Enqueue.
+type EnqueueBuilder struct{ v Enqueue }
+
+func (e *EnqueueBuilder) build() interface{}                   { return e.v }
+func (e *EnqueueBuilder) Action(url string) *EnqueueBuilder    { e.v.Action = url; return e }
+func (e *EnqueueBuilder) Method(method string) *EnqueueBuilder { e.v.Method = method; return e }
+func (e *EnqueueBuilder) WaitUrl(url string) *EnqueueBuilder   { e.v.WaitUrl = url; return e }
+func (e *EnqueueBuilder) WaitUrlMethod(method string) *EnqueueBuilder {
+	e.v.WaitUrlMethod = method
+	return e
+}
+func (e *EnqueueBuilder) WorkflowSid(sid string) *EnqueueBuilder {
+	e.v.WorkflowSid = sid
+	return e
+}
+
+// Task sets the TaskRouter task created for the call, with attributes
+// holding a JSON object. The workflow replaces the queue name.
+func (e *EnqueueBuilder) Task(attributes string) *TaskBuilder {
+	e.v.Task = &Task{Attributes: attributes}
+	return &TaskBuilder{e.v.Task}
+}
+
+// TaskBuilder sets the attributes of an Enqueue Task.
+type TaskBuilder struct{ v *Task }
+
+func (t *TaskBuilder) Priority(priority int) *TaskBuilder { t.v.Priority = priority; return t }
+func (t *TaskBuilder) Timeout(seconds int) *TaskBuilder   { t.v.Timeout = seconds; return t }
+
+// GatherBuilder sets the attributes and nested verbs of a Gather.
+type GatherBuilder struct {
+	v     Gather
+	verbs []builder
+}
+
+func (g *GatherBuilder) build() interface{} {
+	v := g.v
+	v.Nested = buildAll(g.verbs)
+	return v
+}
+
+func (g *GatherBuilder) Action(url string) *GatherBuilder    { g.v.Action = url; return g }
+func (g *GatherBuilder) Method(method string) *GatherBuilder { g.v.Method = method; return g }
+func (g *GatherBuilder) Timeout(seconds int) *GatherBuilder  { g.v.Timeout = &seconds; return g }
+func (g *GatherBuilder) FinishOnKey(key string) *GatherBuilder {
+	g.v.FinishOnKey = key
+	return g
+}
+func (g *GatherBuilder) NumDigits(n int) *GatherBuilder { g.v.NumDigits = n; return g }
+func (g *GatherBuilder) Input(input string) *GatherBuilder {
+	g.v.Input = input
+	return g
+}
+func (g *GatherBuilder) Hints(hints string) *GatherBuilder { g.v.Hints = hints; return g }
+func (g *GatherBuilder) Language(language string) *GatherBuilder {
+	g.v.Language = language
+	return g
+}
+func (g *GatherBuilder) SpeechTimeout(timeout string) *GatherBuilder {
+	g.v.SpeechTimeout = timeout
+	return g
+}
+func (g *GatherBuilder) SpeechModel(model string) *GatherBuilder {
+	g.v.SpeechModel = model
+	return g
+}
+func (g *GatherBuilder) Enhanced(enhanced bool) *GatherBuilder {
+	g.v.Enhanced = &enhanced
+	return g
+}
+func (g *GatherBuilder) ProfanityFilter(filter bool) *GatherBuilder {
+	g.v.ProfanityFilter = &filter
+	return g
+}
+func (g *GatherBuilder) PartialResultCallback(url string) *GatherBuilder {
+	g.v.PartialResultCallback = url
+	return g
+}
+func (g *GatherBuilder) PartialResultCallbackMethod(method string) *GatherBuilder {
+	g.v.PartialResultCallbackMethod = method
+	return g
+}
+func (g *GatherBuilder) ActionOnEmptyResult(always bool) *GatherBuilder {
+	g.v.ActionOnEmptyResult = &always
+	return g
+}
+
+// Pause adds a Pause of length seconds to the Gather prompt.
+func (g *GatherBuilder) Pause(length int) *GatherBuilder {
+	g.verbs = append(g.verbs, valueBuilder{Pause{Length: length}})
+	return g
+}
+
+// Play adds a Play of the audio at url to the Gather prompt.
+func (g *GatherBuilder) Play(url string) *PlayBuilder {
+	p := &PlayBuilder{v: Play{Url: url}}
+	g.verbs = append(g.verbs, p)
+	return p
+}
+
+// Say adds a Say of text to the Gather prompt.
+func (g *GatherBuilder) Say(text string) *SayBuilder {
+	s := &SayBuilder{v: Say{Text: text}}
+	g.verbs = append(g.verbs, s)
+	return s
+}
+
+// MessageBuilder sets the attributes of a Message.
+type MessageBuilder struct{ v Message }
+
+func (m *MessageBuilder) build() interface{}                   { return m.v }
+func (m *MessageBuilder) To(number string) *MessageBuilder     { m.v.To = number; return m }
+func (m *MessageBuilder) From(number string) *MessageBuilder   { m.v.From = number; return m }
+func (m *MessageBuilder) Action(url string) *MessageBuilder    { m.v.Action = url; return m }
+func (m *MessageBuilder) Method(method string) *MessageBuilder { m.v.Method = method; return m }
+func (m *MessageBuilder) StatusCallback(url string) *MessageBuilder {
+	m.v.StatusCallback = url
+	return m
+}
+func (m *MessageBuilder) Media(url string) *MessageBuilder { m.v.Media = url; return m }
+
+// PayBuilder sets the attributes, prompts and parameters of a Pay.
+type PayBuilder struct {
+	v       Pay
+	prompts []builder
+}
+
+func (p *PayBuilder) build() interface{} {
+	v := p.v
+	v.Nested = buildAll(p.prompts)
+	return v
+}
+
+func (p *PayBuilder) Input(input string) *PayBuilder { p.v.Input = input; return p }
+func (p *PayBuilder) Action(url string) *PayBuilder  { p.v.Action = url; return p }
+func (p *PayBuilder) StatusCallback(url string) *PayBuilder {
+	p.v.StatusCallback = url
+	return p
+}
+func (p *PayBuilder) StatusCallbackMethod(method string) *PayBuilder {
+	p.v.StatusCallbackMethod = method
+	return p
+}
+func (p *PayBuilder) Timeout(seconds int) *PayBuilder { p.v.Timeout = seconds; return p }
+func (p *PayBuilder) MaxAttempts(n int) *PayBuilder   { p.v.MaxAttempts = n; return p }
+func (p *PayBuilder) SecurityCode(ask bool) *PayBuilder {
+	p.v.SecurityCode = &ask
+	return p
+}
+func (p *PayBuilder) PostalCode(postalCode string) *PayBuilder {
+	p.v.PostalCode = postalCode
+	return p
+}
+func (p *PayBuilder) MinPostalCodeLength(n int) *PayBuilder {
+	p.v.MinPostalCodeLength = n
+	return p
+}
+func (p *PayBuilder) PaymentMethod(method string) *PayBuilder {
+	p.v.PaymentMethod = method
+	return p
+}
+func (p *PayBuilder) BankAccountType(accountType string) *PayBuilder {
+	p.v.BankAccountType = accountType
+	return p
+}
+func (p *PayBuilder) TokenType(tokenType string) *PayBuilder {
+	p.v.TokenType = tokenType
+	return p
+}
+
+// ChargeAmount charges amount in currency, e.g. "10.50" and "usd".
+func (p *PayBuilder) ChargeAmount(amount, currency string) *PayBuilder {
+	p.v.ChargeAmount, p.v.Currency = amount, currency
+	return p
+}
+func (p *PayBuilder) Description(description string) *PayBuilder {
+	p.v.Description = description
+	return p
+}
+func (p *PayBuilder) ValidCardTypes(cardTypes string) *PayBuilder {
+	p.v.ValidCardTypes = cardTypes
+	return p
+}
+func (p *PayBuilder) Language(language string) *PayBuilder {
+	p.v.Language = language
+	return p
+}
+
+// Prompt adds a Prompt replacing the default prompt for input, e.g.
+// TwiPayCardNumber.
+func (p *PayBuilder) Prompt(input string) *PromptBuilder {
+	pr := &PromptBuilder{v: Prompt{For: input}}
+	p.prompts = append(p.prompts, pr)
+	return pr
+}
+
+// Parameter adds a parameter passed on to the payment connector.
+func (p *PayBuilder) Parameter(name, value string) *PayBuilder {
+	p.v.Parameters = append(p.v.Parameters, Parameter{Name: name, Value: value})
+	return p
+}
+
+// PromptBuilder sets the attributes and nested verbs of a Pay Prompt.
+type PromptBuilder struct {
+	v     Prompt
+	verbs []builder
+}
+
+func (p *PromptBuilder) build() interface{} {
+	v := p.v
+	v.Nested = buildAll(p.verbs)
+	return v
+}
+
+func (p *PromptBuilder) Attempt(attempts string) *PromptBuilder {
+	p.v.Attempt = attempts
+	return p
+}
+func (p *PromptBuilder) CardType(cardTypes string) *PromptBuilder {
+	p.v.CardType = cardTypes
+	return p
+}
+func (p *PromptBuilder) ErrorType(errorTypes string) *PromptBuilder {
+	p.v.ErrorType = errorTypes
+	return p
+}
+func (p *PromptBuilder) RequireMatchingInputs(require bool) *PromptBuilder {
+	p.v.RequireMatchingInputs = &require
+	return p
+}
+
+// Pause adds a Pause of length seconds to the prompt.
+func (p *PromptBuilder) Pause(length int) *PromptBuilder {
+	p.verbs = append(p.verbs, valueBuilder{Pause{Length: length}})
+	return p
+}
+
+// Play adds a Play of the audio at url to the prompt.
+func (p *PromptBuilder) Play(url string) *PlayBuilder {
+	pl := &PlayBuilder{v: Play{Url: url}}
+	p.verbs = append(p.verbs, pl)
+	return pl
+}
+
+// Say adds a Say of text to the prompt.
+func (p *PromptBuilder) Say(text string) *SayBuilder {
+	s := &SayBuilder{v: Say{Text: text}}
+	p.verbs = append(p.verbs, s)
+	return s
+}
+
+// PlayBuilder sets the attributes of a Play.
+type PlayBuilder struct{ v Play }
+
+func (p *PlayBuilder) build() interface{}           { return p.v }
+func (p *PlayBuilder) Loop(n int) *PlayBuilder      { p.v.Loop = &n; return p }
+func (p *PlayBuilder) Digits(d string) *PlayBuilder { p.v.Digits = d; return p }
+
+// RecordBuilder sets the attributes of a Record.
+type RecordBuilder struct{ v Record }
+
+func (r *RecordBuilder) build() interface{}                  { return r.v }
+func (r *RecordBuilder) Action(url string) *RecordBuilder    { r.v.Action = url; return r }
+func (r *RecordBuilder) Method(method string) *RecordBuilder { r.v.Method = method; return r }
+func (r *RecordBuilder) Timeout(seconds int) *RecordBuilder  { r.v.Timeout = &seconds; return r }
+func (r *RecordBuilder) FinishOnKey(key string) *RecordBuilder {
+	r.v.FinishOnKey = key
+	return r
+}
+func (r *RecordBuilder) MaxLength(seconds int) *RecordBuilder {
+	r.v.MaxLength = seconds
+	return r
+}
+func (r *RecordBuilder) Transcribe(callback string) *RecordBuilder {
+	r.v.Transcribe = Bool(true)
+	r.v.TranscribeCallback = callback
+	return r
+}
+func (r *RecordBuilder) PlayBeep(beep bool) *RecordBuilder {
+	r.v.PlayBeep = &beep
+	return r
+}
+
+// RedirectBuilder sets the attributes of a Redirect.
+type RedirectBuilder struct{ v Redirect }
+
+func (r *RedirectBuilder) build() interface{} { return r.v }
+func (r *RedirectBuilder) Method(method string) *RedirectBuilder {
+	r.v.Method = method
+	return r
+}
+
+// RejectBuilder sets the attributes of a Reject.
+type RejectBuilder struct{ v Reject }
+
+func (r *RejectBuilder) build() interface{}                  { return r.v }
+func (r *RejectBuilder) Reason(reason string) *RejectBuilder { r.v.Reason = reason; return r }
+
+// StartBuilder sets the attributes and noun of a Start.
+type StartBuilder struct {
+	v     Start
+	nouns []builder
+}
+
+func (s *StartBuilder) build() interface{} {
+	v := s.v
+	v.Nested = buildAll(s.nouns)
+	return v
+}
+
+func (s *StartBuilder) Action(url string) *StartBuilder    { s.v.Action = url; return s }
+func (s *StartBuilder) Method(method string) *StartBuilder { s.v.Method = method; return s }
+
+// Stream adds a Stream noun forking the call audio to the websocket at url.
+func (s *StartBuilder) Stream(url string) *StreamBuilder {
+	st := &StreamBuilder{v: Stream{Url: url}}
+	s.nouns = append(s.nouns, st)
+	return st
+}
+
+// StopBuilder sets the noun of a Stop.
+type StopBuilder struct {
+	nouns []builder
+}
+
+func (s *StopBuilder) build() interface{} {
+	return Stop{Nested: buildAll(s.nouns)}
+}
+
+// Stream adds a Stream noun stopping the named stream.
+func (s *StopBuilder) Stream(name string) *StreamBuilder {
+	st := &StreamBuilder{v: Stream{Name: name}}
+	s.nouns = append(s.nouns, st)
+	return st
+}
+
+// StreamBuilder sets the attributes and parameters of a Stream.
+type StreamBuilder struct{ v Stream }
+
+func (s *StreamBuilder) build() interface{}              { return s.v }
+func (s *StreamBuilder) Name(name string) *StreamBuilder { s.v.Name = name; return s }
+func (s *StreamBuilder) Track(track string) *StreamBuilder {
+	s.v.Track = track
+	return s
+}
+func (s *StreamBuilder) StatusCallback(url string) *StreamBuilder {
+	s.v.StatusCallback = url
+	return s
+}
+func (s *StreamBuilder) StatusCallbackMethod(method string) *StreamBuilder {
+	s.v.StatusCallbackMethod = method
+	return s
+}
+
+// Parameter adds a custom parameter passed on to the websocket.
+func (s *StreamBuilder) Parameter(name, value string) *StreamBuilder {
+	s.v.Parameters = append(s.v.Parameters, Parameter{Name: name, Value: value})
+	return s
+}
+
+// SayBuilder sets the attributes of a Say.
+type SayBuilder struct{ v Say }
+
+func (s *SayBuilder) build() interface{}                   { return s.v }
+func (s *SayBuilder) Voice(voice string) *SayBuilder       { s.v.Voice = voice; return s }
+func (s *SayBuilder) Language(language string) *SayBuilder { s.v.Language = language; return s }
+func (s *SayBuilder) Loop(n int) *SayBuilder               { s.v.Loop = &n; return s }
+
+// SSML adds SSML elements to the Say, spoken after its text, e.g.
+// SSMLProsody or SSMLLang.
+func (s *SayBuilder) SSML(elements ...interface{}) *SayBuilder {
+	s.v.Nested = append(s.v.Nested, elements...)
+	return s
+}
+
+// Text adds text after the SSML elements added so far.
+func (s *SayBuilder) Text(text string) *SayBuilder {
+	return s.SSML(SSMLText(text))
+}
+
+// Break adds a pause of a time, e.g. "500ms".
+func (s *SayBuilder) Break(time string) *SayBuilder {
+	return s.SSML(SSMLBreak{Time: time})
+}
+
+// SayAs adds text spoken as a type of value, e.g. "digits" or "date".
+func (s *SayBuilder) SayAs(interpretAs, text string) *SayBuilder {
+	return s.SSML(SSMLSayAs{InterpretAs: interpretAs, Text: text})
+}
+
+// Emphasis adds text spoken with an emphasis level, e.g. "strong".
+func (s *SayBuilder) Emphasis(level, text string) *SayBuilder {
+	return s.SSML(SSMLEmphasis{Level: level, Text: text})
+}
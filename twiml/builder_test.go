@@ -0,0 +1,47 @@
+package twiml_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tmc/twilio/twiml"
+)
+
+func TestBuilderMatchesResponse(t *testing.T) {
+	b := twiml.NewBuilder()
+	b.Say("Welcome").Voice(twiml.TwiAlice).Language(twiml.TwiEnglishUSA)
+	g := b.Gather().Action("/menu").NumDigits(1)
+	g.Say("Press 1")
+	g.Pause(2)
+	b.Dial().CallerId("+15005550006").Timeout(10).
+		Number("+15005550001").SendDigits("ww1")
+	b.Hangup()
+
+	want := twiml.NewResponse()
+	want.Action(twiml.Say{Text: "Welcome", Voice: twiml.TwiAlice,
+		Language: twiml.TwiEnglishUSA})
+	want.Gather(twiml.Gather{Action: "/menu", NumDigits: 1},
+		twiml.Say{Text: "Press 1"}, twiml.Pause{Length: 2})
+	want.Dial(twiml.Dial{CallerId: "+15005550006", Timeout: 10},
+		twiml.Number{Number: "+15005550001", SendDigits: "ww1"})
+	want.Action(twiml.Hangup{})
+
+	if got := b.Response().String(); got != want.String() {
+		t.Errorf("built\n%s\nwant\n%s", got, want)
+	}
+}
+
+func ExampleBuilder() {
+	b := twiml.NewBuilder()
+	b.Gather().Action("/menu").NumDigits(1).Say("Press 1 for sales")
+	b.Redirect("/menu")
+	fmt.Println(b.Response())
+	// output:
+	// <?xml version="1.0" encoding="UTF-8"?>
+	//   <Response>
+	//       <Gather action="/menu" numDigits="1">
+	//           <Say>Press 1 for sales</Say>
+	//       </Gather>
+	//       <Redirect>/menu</Redirect>
+	//   </Response>
+}
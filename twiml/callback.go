@@ -0,0 +1,569 @@
+package twiml
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// MessageStatus is the delivery state of a message.
+type MessageStatus string
+
+// Valid reports whether s is one of the message statuses known to twilio.
+func (s MessageStatus) Valid() bool {
+	switch s {
+	case TwiAccepted, TwiQueued, TwiSending, TwiSent, TwiDelivered,
+		TwiUndelivered, TwiFailed, TwiReceived, TwiRead:
+		return true
+	}
+	return false
+}
+
+// MessageStatusRequest holds the parameters twilio sends to the
+// StatusCallback of an outgoing message.
+type MessageStatusRequest struct {
+	MessageSid          string
+	AccountSid          string
+	MessagingServiceSid string
+	From                string
+	To                  string
+	MessageStatus       MessageStatus
+	ErrorCode           int // set for undelivered and failed messages
+	ErrorMessage        string
+	ApiVersion          string
+}
+
+// ParseMessageStatusRequest parses the form of a message status callback.
+func ParseMessageStatusRequest(r *http.Request) (*MessageStatusRequest, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	return parseMessageStatusRequest(r.Form)
+}
+
+func parseMessageStatusRequest(form url.Values) (*MessageStatusRequest, error) {
+	mr := &MessageStatusRequest{
+		MessageSid:          form.Get(TwiMessageSid),
+		AccountSid:          form.Get(TwiAccountSid),
+		MessagingServiceSid: form.Get(TwiMessagingServiceSid),
+		From:                form.Get(TwiFrom),
+		To:                  form.Get(TwiTo),
+		MessageStatus:       MessageStatus(form.Get(TwiMessageStatus)),
+		ErrorMessage:        form.Get(TwiErrorMessage),
+		ApiVersion:          form.Get(TwiApiVersion),
+	}
+	// Older callbacks only carry the SmsSid and SmsStatus
+	if mr.MessageSid == "" {
+		mr.MessageSid = form.Get(TwiSmsSid)
+	}
+	if mr.MessageStatus == "" {
+		mr.MessageStatus = MessageStatus(form.Get(TwiSmsStatus))
+	}
+	if mr.MessageSid == "" {
+		return nil, requiredParams(form, TwiMessageSid)
+	}
+	if err := requiredParams(form, TwiAccountSid); err != nil {
+		return nil, err
+	}
+	if !mr.MessageStatus.Valid() {
+		return nil, invalidParam(TwiMessageStatus, string(mr.MessageStatus))
+	}
+
+	var err error
+	if mr.ErrorCode, err = intParam(form, TwiErrorCode); err != nil {
+		return nil, err
+	}
+	return mr, nil
+}
+
+// RecordingStatus is the state of a recording.
+type RecordingStatus string
+
+// Valid reports whether s is one of the recording statuses known to twilio.
+func (s RecordingStatus) Valid() bool {
+	switch s {
+	case TwiInProgress, TwiCompleted, TwiAbsent, TwiFailed:
+		return true
+	}
+	return false
+}
+
+// RecordingStatusRequest holds the parameters twilio sends to the
+// recordingStatusCallback of a recording.
+type RecordingStatusRequest struct {
+	AccountSid         string
+	CallSid            string
+	RecordingSid       string
+	RecordingUrl       string
+	RecordingStatus    RecordingStatus
+	RecordingDuration  time.Duration
+	RecordingChannels  int
+	RecordingSource    string
+	RecordingStartTime time.Time
+	ErrorCode          int
+}
+
+// ParseRecordingStatusRequest parses the form of a recording status callback.
+func ParseRecordingStatusRequest(r *http.Request) (*RecordingStatusRequest, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	return parseRecordingStatusRequest(r.Form)
+}
+
+func parseRecordingStatusRequest(form url.Values) (*RecordingStatusRequest, error) {
+	rr := &RecordingStatusRequest{
+		AccountSid:      form.Get(TwiAccountSid),
+		CallSid:         form.Get(TwiCallSid),
+		RecordingSid:    form.Get(TwiRecordingSid),
+		RecordingUrl:    form.Get(TwiRecordingUrl),
+		RecordingStatus: RecordingStatus(form.Get(TwiRecordingStatus)),
+		RecordingSource: form.Get(TwiRecordingSource),
+	}
+	if err := requiredParams(form, TwiAccountSid, TwiRecordingSid); err != nil {
+		return nil, err
+	}
+	if !rr.RecordingStatus.Valid() {
+		return nil, invalidParam(TwiRecordingStatus, string(rr.RecordingStatus))
+	}
+
+	var err error
+	if rr.RecordingDuration, err = secondsParam(form, TwiRecordingDuration); err != nil {
+		return nil, err
+	}
+	if rr.RecordingChannels, err = intParam(form, TwiRecordingChannels); err != nil {
+		return nil, err
+	}
+	if rr.RecordingStartTime, err = timeParam(form, TwiRecordingStartTime); err != nil {
+		return nil, err
+	}
+	if rr.ErrorCode, err = intParam(form, TwiErrorCode); err != nil {
+		return nil, err
+	}
+	return rr, nil
+}
+
+// TranscriptionRequest holds the parameters twilio sends to the
+// transcribeCallback of a Record verb.
+type TranscriptionRequest struct {
+	AccountSid          string
+	CallSid             string
+	TranscriptionSid    string
+	TranscriptionText   string
+	TranscriptionStatus string // completed or failed
+	TranscriptionUrl    string
+	RecordingSid        string
+	RecordingUrl        string
+}
+
+// ParseTranscriptionRequest parses the form of a transcription callback.
+func ParseTranscriptionRequest(r *http.Request) (*TranscriptionRequest, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	return parseTranscriptionRequest(r.Form)
+}
+
+func parseTranscriptionRequest(form url.Values) (*TranscriptionRequest, error) {
+	tr := &TranscriptionRequest{
+		AccountSid:          form.Get(TwiAccountSid),
+		CallSid:             form.Get(TwiCallSid),
+		TranscriptionSid:    form.Get(TwiTranscriptionSid),
+		TranscriptionText:   form.Get(TwiTranscriptionText),
+		TranscriptionStatus: form.Get(TwiTranscriptionStatus),
+		TranscriptionUrl:    form.Get(TwiTranscriptionUrl),
+		RecordingSid:        form.Get(TwiRecordingSid),
+		RecordingUrl:        form.Get(TwiRecordingUrl),
+	}
+	if err := requiredParams(form, TwiAccountSid, TwiTranscriptionSid); err != nil {
+		return nil, err
+	}
+	switch tr.TranscriptionStatus {
+	case TwiCompleted, TwiFailed:
+	default:
+		return nil, invalidParam(TwiTranscriptionStatus, tr.TranscriptionStatus)
+	}
+	return tr, nil
+}
+
+// GatherRequest holds the parameters twilio sends to the action of a Gather
+// with the digits or speech of the caller, and to its partialResultCallback
+// while the caller is still speaking.
+type GatherRequest struct {
+	VoiceRequest
+	// Partial result callback parameters: the speech recognized so far that
+	// won't change, the speech that may still change, and how likely it is
+	// to change from 0 to 1. Partial results may arrive out of order, the
+	// SequenceNumber of the VoiceRequest orders them.
+	StableSpeechResult   string
+	UnstableSpeechResult string
+	Stability            float64
+}
+
+// Empty reports whether the caller entered neither digits nor speech, which
+// is only sent to the action of a Gather with ActionOnEmptyResult.
+func (gr *GatherRequest) Empty() bool {
+	return gr.Digits == "" && gr.SpeechResult == "" &&
+		gr.StableSpeechResult == "" && gr.UnstableSpeechResult == ""
+}
+
+// ParseGatherRequest parses the form of a Gather action or partial result
+// callback.
+func ParseGatherRequest(r *http.Request) (*GatherRequest, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	return parseGatherRequest(r.Form)
+}
+
+func parseGatherRequest(form url.Values) (*GatherRequest, error) {
+	vr, err := parseVoiceRequest(form)
+	if err != nil {
+		return nil, err
+	}
+	gr := &GatherRequest{
+		VoiceRequest:         *vr,
+		StableSpeechResult:   form.Get(TwiStableSpeechResult),
+		UnstableSpeechResult: form.Get(TwiUnstableSpeechResult),
+	}
+	if gr.Stability, err = floatParam(form, TwiStability); err != nil {
+		return nil, err
+	}
+	return gr, nil
+}
+
+// PayResult is the outcome of a Pay verb.
+type PayResult string
+
+// Valid reports whether r is one of the Pay results known to twilio.
+func (r PayResult) Valid() bool {
+	switch r {
+	case TwiSuccess, TwiTooManyFailedAttempts, TwiPaymentConnectorError,
+		TwiCallerInterruptedStar, TwiCallerHungUp, TwiValidationError:
+		return true
+	}
+	return false
+}
+
+// PayRequest holds the parameters twilio sends to the action of a Pay verb
+// once the payment is processed or abandoned.
+type PayRequest struct {
+	VoiceRequest
+	Result                  PayResult
+	PaymentToken            string
+	ProfileId               string
+	PaymentConfirmationCode string
+	PaymentMethod           string
+	// Masked payment details, e.g. "xxxx-xxxx-xxxx-1111"
+	PaymentCardNumber     string
+	PaymentCardType       string
+	PaymentCardPostalCode string
+	ExpirationDate        string
+	SecurityCode          string
+	BankAccountNumber     string
+	BankRoutingNumber     string
+	// Set when the payment failed
+	PaymentError     string
+	PaymentErrorCode int
+	ConnectorError   string
+}
+
+// Succeeded reports whether the payment was processed.
+func (pr *PayRequest) Succeeded() bool {
+	return pr.Result == TwiSuccess
+}
+
+// ParsePayRequest parses the form of a Pay action request.
+func ParsePayRequest(r *http.Request) (*PayRequest, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	return parsePayRequest(r.Form)
+}
+
+func parsePayRequest(form url.Values) (*PayRequest, error) {
+	vr, err := parseVoiceRequest(form)
+	if err != nil {
+		return nil, err
+	}
+	pr := &PayRequest{
+		VoiceRequest:            *vr,
+		Result:                  PayResult(form.Get(TwiResult)),
+		PaymentToken:            form.Get(TwiPaymentToken),
+		ProfileId:               form.Get(TwiProfileId),
+		PaymentConfirmationCode: form.Get(TwiPaymentConfirmationCode),
+		PaymentMethod:           form.Get(TwiPaymentMethod),
+		PaymentCardNumber:       form.Get(TwiPaymentCardNumber),
+		PaymentCardType:         form.Get(TwiPaymentCardType),
+		PaymentCardPostalCode:   form.Get(TwiPaymentCardPostalCode),
+		ExpirationDate:          form.Get(TwiExpirationDate),
+		SecurityCode:            form.Get(TwiSecurityCode),
+		BankAccountNumber:       form.Get(TwiBankAccountNumber),
+		BankRoutingNumber:       form.Get(TwiBankRoutingNumber),
+		PaymentError:            form.Get(TwiPaymentError),
+		ConnectorError:          form.Get(TwiConnectorError),
+	}
+	if !pr.Result.Valid() {
+		return nil, invalidParam(TwiResult, string(pr.Result))
+	}
+	if pr.PaymentErrorCode, err = intParam(form, TwiPaymentErrorCode); err != nil {
+		return nil, err
+	}
+	return pr, nil
+}
+
+// PayStatusRequest holds the parameters twilio sends to the statusCallback
+// of a Pay verb as the caller enters each input.
+type PayStatusRequest struct {
+	AccountSid string
+	CallSid    string
+	// For is the input being captured, e.g. TwiPayCardNumber
+	For     string
+	Attempt int
+	// ErrorType is set when the input was not valid, e.g.
+	// "invalid-card-number"
+	ErrorType string
+	// PartialResult is true while the caller is still entering For
+	PartialResult bool
+	// Required lists the inputs still to be captured
+	Required []string
+	// Masked payment details captured so far
+	PaymentCardNumber     string
+	PaymentCardType       string
+	PaymentCardPostalCode string
+	ExpirationDate        string
+	SecurityCode          string
+	BankAccountNumber     string
+	BankRoutingNumber     string
+}
+
+// ParsePayStatusRequest parses the form of a Pay status callback.
+func ParsePayStatusRequest(r *http.Request) (*PayStatusRequest, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	return parsePayStatusRequest(r.Form)
+}
+
+func parsePayStatusRequest(form url.Values) (*PayStatusRequest, error) {
+	sr := &PayStatusRequest{
+		AccountSid:            form.Get(TwiAccountSid),
+		CallSid:               form.Get(TwiCallSid),
+		For:                   form.Get(TwiFor),
+		ErrorType:             form.Get(TwiErrorType),
+		PaymentCardNumber:     form.Get(TwiPaymentCardNumber),
+		PaymentCardType:       form.Get(TwiPaymentCardType),
+		PaymentCardPostalCode: form.Get(TwiPaymentCardPostalCode),
+		ExpirationDate:        form.Get(TwiExpirationDate),
+		SecurityCode:          form.Get(TwiSecurityCode),
+		BankAccountNumber:     form.Get(TwiBankAccountNumber),
+		BankRoutingNumber:     form.Get(TwiBankRoutingNumber),
+	}
+	if err := requiredParams(form, TwiAccountSid, TwiCallSid); err != nil {
+		return nil, err
+	}
+	for _, r := range strings.Split(form.Get(TwiRequired), ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			sr.Required = append(sr.Required, r)
+		}
+	}
+
+	var err error
+	if sr.Attempt, err = intParam(form, TwiAttempt); err != nil {
+		return nil, err
+	}
+	if sr.PartialResult, err = boolParam(form, TwiPartialResult); err != nil {
+		return nil, err
+	}
+	return sr, nil
+}
+
+// QueueResult is the outcome of an Enqueue.
+type QueueResult string
+
+// Valid reports whether r is one of the Enqueue results known to twilio.
+func (r QueueResult) Valid() bool {
+	switch r {
+	case TwiBridged, TwiBridgingInProgress, TwiQueueFull, TwiError, TwiHangup,
+		TwiRedirected, TwiLeave, TwiSystemError:
+		return true
+	}
+	return false
+}
+
+// EnqueueRequest holds the parameters twilio sends to the action of an
+// Enqueue when the call leaves the queue.
+type EnqueueRequest struct {
+	VoiceRequest
+	QueueResult QueueResult
+	QueueSid    string
+	QueueTime   time.Duration
+}
+
+// ParseEnqueueRequest parses the form of an Enqueue action request.
+func ParseEnqueueRequest(r *http.Request) (*EnqueueRequest, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	return parseEnqueueRequest(r.Form)
+}
+
+func parseEnqueueRequest(form url.Values) (*EnqueueRequest, error) {
+	vr, err := parseVoiceRequest(form)
+	if err != nil {
+		return nil, err
+	}
+	er := &EnqueueRequest{
+		VoiceRequest: *vr,
+		QueueResult:  QueueResult(form.Get(TwiQueueResult)),
+		QueueSid:     form.Get(TwiQueueSid),
+	}
+	if !er.QueueResult.Valid() {
+		return nil, invalidParam(TwiQueueResult, string(er.QueueResult))
+	}
+	if er.QueueTime, err = secondsParam(form, TwiQueueTime); err != nil {
+		return nil, err
+	}
+	return er, nil
+}
+
+// QueueWaitRequest holds the parameters twilio sends to the waitUrl of an
+// Enqueue while the caller waits in the queue.
+type QueueWaitRequest struct {
+	VoiceRequest
+	QueueSid string
+	// QueuePosition is the caller's position in the queue, 1 being next
+	QueuePosition    int
+	QueueTime        time.Duration
+	AvgQueueTime     time.Duration
+	CurrentQueueSize int
+	MaxQueueSize     int
+}
+
+// ParseQueueWaitRequest parses the form of an Enqueue waitUrl request.
+func ParseQueueWaitRequest(r *http.Request) (*QueueWaitRequest, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	return parseQueueWaitRequest(r.Form)
+}
+
+func parseQueueWaitRequest(form url.Values) (*QueueWaitRequest, error) {
+	vr, err := parseVoiceRequest(form)
+	if err != nil {
+		return nil, err
+	}
+	wr := &QueueWaitRequest{VoiceRequest: *vr, QueueSid: form.Get(TwiQueueSid)}
+	if err := requiredParams(form, TwiQueueSid); err != nil {
+		return nil, err
+	}
+	if wr.QueuePosition, err = intParam(form, TwiQueuePosition); err != nil {
+		return nil, err
+	}
+	if wr.QueueTime, err = secondsParam(form, TwiQueueTime); err != nil {
+		return nil, err
+	}
+	if wr.AvgQueueTime, err = secondsParam(form, TwiAvgQueueTime); err != nil {
+		return nil, err
+	}
+	if wr.CurrentQueueSize, err = intParam(form, TwiCurrentQueueSize); err != nil {
+		return nil, err
+	}
+	if wr.MaxQueueSize, err = intParam(form, TwiMaxQueueSize); err != nil {
+		return nil, err
+	}
+	return wr, nil
+}
+
+// ConferenceEvent is the event of a conference status callback.
+type ConferenceEvent string
+
+// Valid reports whether e is one of the conference events known to twilio.
+func (e ConferenceEvent) Valid() bool {
+	switch e {
+	case TwiEventConferenceStart, TwiEventConferenceEnd, TwiEventParticipantJoin,
+		TwiEventParticipantLeave, TwiEventParticipantMute, TwiEventParticipantUnmute,
+		TwiEventParticipantHold, TwiEventParticipantUnhold, TwiEventParticipantModify,
+		TwiEventParticipantSpeechStart, TwiEventParticipantSpeechStop,
+		TwiEventAnnouncementEnd, TwiEventAnnouncementFail:
+		return true
+	}
+	return false
+}
+
+// ConferenceStatusRequest holds the parameters twilio sends to the
+// statusCallback of a Conference for the events of its statusCallbackEvent.
+type ConferenceStatusRequest struct {
+	ConferenceSid       string
+	FriendlyName        string
+	AccountSid          string
+	StatusCallbackEvent ConferenceEvent
+	SequenceNumber      int
+	Timestamp           time.Time
+	// Participant event parameters, with the state of the participant
+	// after the event
+	CallSid                string
+	ParticipantLabel       string
+	Muted                  bool
+	Hold                   bool
+	Coaching               bool
+	CallSidToCoach         string
+	EndConferenceOnExit    bool
+	StartConferenceOnEnter bool
+	// Conference end parameters
+	ReasonConferenceEnded   string
+	CallSidEndingConference string
+	// Announcement failure parameters
+	ReasonAnnouncementFailed string
+}
+
+// ParseConferenceStatusRequest parses the form of a conference status
+// callback.
+func ParseConferenceStatusRequest(r *http.Request) (*ConferenceStatusRequest, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	return parseConferenceStatusRequest(r.Form)
+}
+
+func parseConferenceStatusRequest(form url.Values) (*ConferenceStatusRequest, error) {
+	cr := &ConferenceStatusRequest{
+		ConferenceSid:            form.Get(TwiConferenceSid),
+		FriendlyName:             form.Get(TwiFriendlyName),
+		AccountSid:               form.Get(TwiAccountSid),
+		StatusCallbackEvent:      ConferenceEvent(form.Get(TwiStatusCallbackEvent)),
+		CallSid:                  form.Get(TwiCallSid),
+		ParticipantLabel:         form.Get(TwiParticipantLabel),
+		CallSidToCoach:           form.Get(TwiCallSidToCoach),
+		ReasonConferenceEnded:    form.Get(TwiReasonConferenceEnded),
+		CallSidEndingConference:  form.Get(TwiCallSidEndingConference),
+		ReasonAnnouncementFailed: form.Get(TwiReasonAnnouncementFailed),
+	}
+	if err := requiredParams(form, TwiConferenceSid, TwiAccountSid); err != nil {
+		return nil, err
+	}
+	if !cr.StatusCallbackEvent.Valid() {
+		return nil, invalidParam(TwiStatusCallbackEvent, string(cr.StatusCallbackEvent))
+	}
+
+	var err error
+	if cr.SequenceNumber, err = intParam(form, TwiSequenceNumber); err != nil {
+		return nil, err
+	}
+	if cr.Timestamp, err = timeParam(form, TwiTimestamp); err != nil {
+		return nil, err
+	}
+	for name, b := range map[string]*bool{
+		TwiMuted:                  &cr.Muted,
+		TwiHold:                   &cr.Hold,
+		TwiCoaching:               &cr.Coaching,
+		TwiEndConferenceOnExit:    &cr.EndConferenceOnExit,
+		TwiStartConferenceOnEnter: &cr.StartConferenceOnEnter,
+	} {
+		if *b, err = boolParam(form, name); err != nil {
+			return nil, err
+		}
+	}
+	return cr, nil
+}
@@ -0,0 +1,115 @@
+package twiml_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/tmc/twilio/twiml"
+)
+
+// paramError is a change to a valid form and the error it makes a parser
+// return
+type paramError struct {
+	params map[string]string
+	err    string
+}
+
+// testParseErrors checks that parse accepts the voice form with params and
+// rejects it with each change of tests
+func testParseErrors(t *testing.T, parse func(*http.Request) error,
+	params map[string]string, tests []paramError) {
+
+	t.Helper()
+	if err := parse(postForm(voiceForm(params))); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		p := map[string]string{}
+		for k, v := range params {
+			p[k] = v
+		}
+		for k, v := range tt.params {
+			p[k] = v
+		}
+		err := parse(postForm(voiceForm(p)))
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("%v: error %v, want %s", tt.params, err, tt.err)
+		}
+	}
+}
+
+func TestParseGatherRequest(t *testing.T) {
+	form := voiceForm(map[string]string{
+		"StableSpeechResult":   "I would like",
+		"UnstableSpeechResult": "to pay",
+		"Stability":            "0.4",
+		"SequenceNumber":       "3",
+	})
+	gr, err := twiml.ParseGatherRequest(postForm(form))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gr.StableSpeechResult != "I would like" || gr.UnstableSpeechResult != "to pay" ||
+		gr.Stability != 0.4 || gr.SequenceNumber != 3 || gr.Empty() {
+		t.Errorf("parsed %+v", gr)
+	}
+
+	testParseErrors(t, func(r *http.Request) error {
+		_, err := twiml.ParseGatherRequest(r)
+		return err
+	}, nil, []paramError{
+		{map[string]string{"CallSid": ""}, "required parameter missing: 'CallSid'"},
+		{map[string]string{"Stability": "stable"}, "non valid Stability: 'stable'"},
+		{map[string]string{"SequenceNumber": "3rd"}, "non valid SequenceNumber: '3rd'"},
+		{map[string]string{"Confidence": "0,9"}, "non valid Confidence: '0,9'"},
+	})
+}
+
+func TestParsePayRequest(t *testing.T) {
+	testParseErrors(t, func(r *http.Request) error {
+		_, err := twiml.ParsePayRequest(r)
+		return err
+	}, map[string]string{"Result": "success"}, []paramError{
+		{map[string]string{"AccountSid": ""}, "required parameter missing: 'AccountSid'"},
+		{map[string]string{"Result": ""}, "non valid Result: ''"},
+		{map[string]string{"Result": "declined"}, "non valid Result: 'declined'"},
+		{map[string]string{"PaymentErrorCode": "E1"}, "non valid PaymentErrorCode: 'E1'"},
+		{map[string]string{"CallStatus": "paying"}, "non valid CallStatus: 'paying'"},
+	})
+
+	testParseErrors(t, func(r *http.Request) error {
+		_, err := twiml.ParsePayStatusRequest(r)
+		return err
+	}, map[string]string{"For": "payment-card-number"}, []paramError{
+		{map[string]string{"CallSid": ""}, "required parameter missing: 'CallSid'"},
+		{map[string]string{"Attempt": "second"}, "non valid Attempt: 'second'"},
+		{map[string]string{"PartialResult": "yes"}, "non valid PartialResult: 'yes'"},
+	})
+}
+
+func TestParseEnqueueRequest(t *testing.T) {
+	testParseErrors(t, func(r *http.Request) error {
+		_, err := twiml.ParseEnqueueRequest(r)
+		return err
+	}, map[string]string{"QueueResult": "bridged", "QueueTime": "30"}, []paramError{
+		{map[string]string{"CallSid": ""}, "required parameter missing: 'CallSid'"},
+		{map[string]string{"QueueResult": ""}, "non valid QueueResult: ''"},
+		{map[string]string{"QueueResult": "abandoned"}, "non valid QueueResult: 'abandoned'"},
+		{map[string]string{"QueueTime": "30s"}, "non valid QueueTime: '30s'"},
+	})
+}
+
+func TestParseQueueWaitRequest(t *testing.T) {
+	testParseErrors(t, func(r *http.Request) error {
+		_, err := twiml.ParseQueueWaitRequest(r)
+		return err
+	}, map[string]string{"QueueSid": "QU1234567890ABCDE", "QueuePosition": "2"}, []paramError{
+		{map[string]string{"AccountSid": ""}, "required parameter missing: 'AccountSid'"},
+		{map[string]string{"QueueSid": ""}, "required parameter missing: 'QueueSid'"},
+		{map[string]string{"QueuePosition": "next"}, "non valid QueuePosition: 'next'"},
+		{map[string]string{"QueueTime": "-"}, "non valid QueueTime: '-'"},
+		{map[string]string{"AvgQueueTime": "1.5"}, "non valid AvgQueueTime: '1.5'"},
+		{map[string]string{"CurrentQueueSize": "many"}, "non valid CurrentQueueSize: 'many'"},
+		{map[string]string{"MaxQueueSize": "none"}, "non valid MaxQueueSize: 'none'"},
+	})
+}
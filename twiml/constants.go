@@ -14,7 +14,7 @@ const (
 	TwiGermanGermany      = "de-DE"
 	TwiEnglishAustralia   = "en-AU"
 	TwiEnglishCanada      = "en-CA"
-	TwiEnglishUK          = "en-UK"
+	TwiEnglishUK          = "en-GB"
 	TwiEnglishIndia       = "en-IN"
 	TwiEnglishUSA         = "en-US"
 	TwiSpanishCatalan     = "ca-ES"
@@ -33,11 +33,72 @@ const (
 	TwiPortuguesePortugal = "pt-PT"
 	TwiRussianRussia      = "ru-RU"
 	TwiSwedishSweden      = "sv-SE"
-	TwiChineseMandarin    = "zh-CH"
+	TwiChineseMandarin    = "zh-CN"
 	TwiChineseCantonese   = "zh-HK"
 	TwiChineseTaiwanese   = "zh-TW"
 )
 
+// Gather input types and speech models
+const (
+	TwiDTMF                      = "dtmf"
+	TwiSpeech                    = "speech"
+	TwiAuto                      = "auto"
+	TwiDefaultModel              = "default"
+	TwiNumbersAndCommands        = "numbers_and_commands"
+	TwiPhoneCall                 = "phone_call"
+	TwiExperimentalConversations = "experimental_conversations"
+	TwiExperimentalUtterances    = "experimental_utterances"
+)
+
+// Stream tracks
+const (
+	TwiInboundTrack  = "inbound_track"
+	TwiOutboundTrack = "outbound_track"
+	TwiBothTracks    = "both_tracks"
+)
+
+// Conference statusCallbackEvent attribute values, selecting the events
+// sent to the statusCallback
+const (
+	TwiConferenceStart        = "start"
+	TwiConferenceEnd          = "end"
+	TwiConferenceJoin         = "join"
+	TwiConferenceLeave        = "leave"
+	TwiConferenceMute         = "mute"
+	TwiConferenceHold         = "hold"
+	TwiConferenceModify       = "modify"
+	TwiConferenceSpeaker      = "speaker"
+	TwiConferenceAnnouncement = "announcement"
+)
+
+// Pay payment methods and token types
+const (
+	TwiCreditCard         = "credit-card"
+	TwiACHDebit           = "ach-debit"
+	TwiOneTime            = "one-time"
+	TwiReusable           = "reusable"
+	TwiPaymentMethodToken = "payment-method"
+)
+
+// Pay inputs, as Prompt for and Pay status callback For values
+const (
+	TwiPayCardNumber        = "payment-card-number"
+	TwiPayExpirationDate    = "expiration-date"
+	TwiPaySecurityCode      = "security-code"
+	TwiPayPostalCode        = "postal-code"
+	TwiPayBankRoutingNumber = "bank-routing-number"
+	TwiPayBankAccountNumber = "bank-account-number"
+	TwiPayProcessing        = "payment-processing"
+)
+
+// Voice engines of the voice catalog
+const (
+	TwiBasic    = "basic"
+	TwiStandard = "standard"
+	TwiNeural   = "neural"
+	TwiGoogle   = "google"
+)
+
 // Twilio callback status parameters for: Call End Callback (StatusCallback),
 // Voice Request
 const (
@@ -50,6 +111,7 @@ const (
 	TwiDirection     = "Direction"
 	TwiForwardedFrom = "ForwardedFrom"
 	TwiCallerName    = "CallerName"
+	TwiParentCallSid = "ParentCallSid"
 	// Geographic data
 	TwiFromCity    = "FromCity"
 	TwiFromState   = "FromState"
@@ -58,17 +120,131 @@ const (
 	TwiToCity      = "ToCity"
 	TwiToState     = "ToState"
 	TwiToZip       = "ToZip"
-	TwiToCountry   = "Tocountry"
+	TwiToCountry   = "ToCountry"
 	//  Status callback
 	TwiCallDuration      = "CallDuration"
 	TwiRecordingUrl      = "RecordingUrl"
 	TwiRecordingSid      = "RecordingSid"
 	TwiRecordingDuration = "RecordingDuration"
+	TwiTimestamp         = "Timestamp"
+	TwiSequenceNumber    = "SequenceNumber"
+	TwiCallbackSource    = "CallbackSource"
+	// Answering machine detection, Gather and Record input
+	TwiAnsweredBy   = "AnsweredBy"
+	TwiDigits       = "Digits"
+	TwiSpeechResult = "SpeechResult"
+	TwiConfidence   = "Confidence"
+	// Below parameters are included in Gather partialResultCallback requests,
+	// along with SequenceNumber
+	TwiStableSpeechResult   = "StableSpeechResult"
+	TwiUnstableSpeechResult = "UnstableSpeechResult"
+	TwiStability            = "Stability"
 	// Below parameters are included in AddCallerId request response
 	TwiVerificationStatus  = "VerificationStatus"
 	TwiOutgoingCallerIdSid = "OutgoingCallerIdSid"
 )
 
+// Twilio parameters for: Incoming Message Request, Message Status Callback
+const (
+	TwiMessageSid          = "MessageSid"
+	TwiSmsSid              = "SmsSid"
+	TwiMessagingServiceSid = "MessagingServiceSid"
+	TwiBody                = "Body"
+	TwiNumMedia            = "NumMedia"
+	TwiNumSegments         = "NumSegments"
+	TwiOptOutType          = "OptOutType"
+	// Media parameters are suffixed with the media index, e.g. MediaUrl0
+	TwiMediaUrl         = "MediaUrl"
+	TwiMediaContentType = "MediaContentType"
+)
+
+// Twilio parameters for: Message Status Callback
+const (
+	TwiMessageStatus = "MessageStatus"
+	TwiSmsStatus     = "SmsStatus"
+	TwiErrorCode     = "ErrorCode"
+	TwiErrorMessage  = "ErrorMessage"
+)
+
+// Twilio parameters for: Recording Status Callback (recordingStatusCallback)
+const (
+	TwiRecordingStatus    = "RecordingStatus"
+	TwiRecordingChannels  = "RecordingChannels"
+	TwiRecordingSource    = "RecordingSource"
+	TwiRecordingStartTime = "RecordingStartTime"
+)
+
+// Twilio parameters for: Transcription Callback (transcribeCallback)
+const (
+	TwiTranscriptionSid    = "TranscriptionSid"
+	TwiTranscriptionText   = "TranscriptionText"
+	TwiTranscriptionStatus = "TranscriptionStatus"
+	TwiTranscriptionUrl    = "TranscriptionUrl"
+)
+
+// Twilio parameters for: Pay Action (action) and Pay Status Callback
+// (statusCallback). The card and bank account numbers are masked.
+const (
+	TwiResult                  = "Result"
+	TwiPaymentToken            = "PaymentToken"
+	TwiProfileId               = "ProfileId"
+	TwiPaymentConfirmationCode = "PaymentConfirmationCode"
+	TwiPaymentMethod           = "PaymentMethod"
+	TwiPaymentCardNumber       = "PaymentCardNumber"
+	TwiPaymentCardType         = "PaymentCardType"
+	TwiPaymentCardPostalCode   = "PaymentCardPostalCode"
+	TwiExpirationDate          = "ExpirationDate"
+	TwiSecurityCode            = "SecurityCode"
+	TwiBankAccountNumber       = "BankAccountNumber"
+	TwiBankRoutingNumber       = "BankRoutingNumber"
+	TwiPaymentError            = "PaymentError"
+	TwiPaymentErrorCode        = "PaymentErrorCode"
+	TwiConnectorError          = "ConnectorError"
+	// Below parameters are included in Pay statusCallback requests
+	TwiFor           = "For"
+	TwiAttempt       = "Attempt"
+	TwiErrorType     = "ErrorType"
+	TwiPartialResult = "PartialResult"
+	TwiRequired      = "Required"
+)
+
+// Twilio parameters for: Enqueue Action (action) and Enqueue Wait Url
+// (waitUrl)
+const (
+	TwiQueueResult = "QueueResult"
+	TwiQueueSid    = "QueueSid"
+	TwiQueueTime   = "QueueTime"
+	// Below parameters are included in waitUrl requests
+	TwiQueuePosition    = "QueuePosition"
+	TwiAvgQueueTime     = "AvgQueueTime"
+	TwiCurrentQueueSize = "CurrentQueueSize"
+	TwiMaxQueueSize     = "MaxQueueSize"
+)
+
+// Twilio parameters for: Conference Status Callback (statusCallback)
+const (
+	TwiConferenceSid            = "ConferenceSid"
+	TwiFriendlyName             = "FriendlyName"
+	TwiStatusCallbackEvent      = "StatusCallbackEvent"
+	TwiMuted                    = "Muted"
+	TwiHold                     = "Hold"
+	TwiCoaching                 = "Coaching"
+	TwiCallSidToCoach           = "CallSidToCoach"
+	TwiParticipantLabel         = "ParticipantLabel"
+	TwiEndConferenceOnExit      = "EndConferenceOnExit"
+	TwiStartConferenceOnEnter   = "StartConferenceOnEnter"
+	TwiReasonConferenceEnded    = "ReasonConferenceEnded"
+	TwiCallSidEndingConference  = "CallSidEndingConference"
+	TwiReasonAnnouncementFailed = "ReasonAnnouncementFailed"
+)
+
+// Opt-out types (OptOutType) for Advanced Opt-Out keywords
+const (
+	TwiOptOutStop  = "STOP"
+	TwiOptOutStart = "START"
+	TwiOptOutHelp  = "HELP"
+)
+
 // Call status
 const (
 	TwiQueued     = "queued"
@@ -80,3 +256,78 @@ const (
 	TwiNoAnswer   = "no-answer"
 	TwiCanceled   = "canceled"
 )
+
+// Call direction
+const (
+	TwiInbound      = "inbound"
+	TwiOutboundApi  = "outbound-api"
+	TwiOutboundDial = "outbound-dial"
+)
+
+// Answering machine detection results (AnsweredBy)
+const (
+	TwiHuman             = "human"
+	TwiMachineStart      = "machine_start"
+	TwiMachineEndBeep    = "machine_end_beep"
+	TwiMachineEndSilence = "machine_end_silence"
+	TwiMachineEndOther   = "machine_end_other"
+	TwiFax               = "fax"
+	TwiUnknown           = "unknown"
+)
+
+// Message status. Queued and failed are shared with the call status.
+const (
+	TwiAccepted    = "accepted"
+	TwiSending     = "sending"
+	TwiSent        = "sent"
+	TwiDelivered   = "delivered"
+	TwiUndelivered = "undelivered"
+	TwiReceived    = "received"
+	TwiRead        = "read"
+)
+
+// Recording status. In-progress, completed and failed are shared with the
+// call status.
+const (
+	TwiAbsent = "absent"
+)
+
+// Pay results (Result)
+const (
+	TwiSuccess               = "success"
+	TwiTooManyFailedAttempts = "too-many-failed-attempts"
+	TwiPaymentConnectorError = "payment-connector-error"
+	TwiCallerInterruptedStar = "caller-interrupted-with-star"
+	TwiCallerHungUp          = "caller-hung-up"
+	TwiValidationError       = "validation-error"
+)
+
+// Enqueue results (QueueResult)
+const (
+	TwiBridged            = "bridged"
+	TwiBridgingInProgress = "bridging-in-progress"
+	TwiQueueFull          = "queue-full"
+	TwiError              = "error"
+	TwiHangup             = "hangup"
+	TwiRedirected         = "redirected"
+	TwiLeave              = "leave"
+	TwiSystemError        = "system-error"
+)
+
+// Conference status callback events (StatusCallbackEvent parameter), as
+// twilio sends them to the statusCallback
+const (
+	TwiEventConferenceStart        = "conference-start"
+	TwiEventConferenceEnd          = "conference-end"
+	TwiEventParticipantJoin        = "participant-join"
+	TwiEventParticipantLeave       = "participant-leave"
+	TwiEventParticipantMute        = "participant-mute"
+	TwiEventParticipantUnmute      = "participant-unmute"
+	TwiEventParticipantHold        = "participant-hold"
+	TwiEventParticipantUnhold      = "participant-unhold"
+	TwiEventParticipantModify      = "participant-modify"
+	TwiEventParticipantSpeechStart = "participant-speech-start"
+	TwiEventParticipantSpeechStop  = "participant-speech-stop"
+	TwiEventAnnouncementEnd        = "announcement-end"
+	TwiEventAnnouncementFail       = "announcement-fail"
+)
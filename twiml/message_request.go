@@ -0,0 +1,203 @@
+package twiml
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+// MediaHosts are the hosts FetchMedia downloads media from. Media urls are
+// taken from the webhook form, so other hosts are refused rather than sent
+// the account credentials.
+var MediaHosts = []string{"api.twilio.com"}
+
+// messageSidPattern matches the sid of a SMS or MMS message
+var messageSidPattern = regexp.MustCompile(`^(SM|MM)[0-9a-f]{32}$`)
+
+// MessageRequest holds the parameters twilio sends with an incoming SMS or MMS
+// message to the application messaging Url.
+type MessageRequest struct {
+	MessageSid          string
+	AccountSid          string
+	MessagingServiceSid string
+	From                string
+	To                  string
+	Body                string
+	NumSegments         int
+	ApiVersion          string
+	OptOutType          string
+	FromGeo             Geo
+	ToGeo               Geo
+	Media               []Media
+}
+
+// Media is a media file attached to an incoming MMS message.
+type Media struct {
+	Url         string
+	ContentType string
+}
+
+// ParseMessageRequest parses the form of a twilio incoming message request.
+// MessageSid and AccountSid are required, and a MediaUrlN parameter must be
+// present for each of the NumMedia attachments.
+func ParseMessageRequest(r *http.Request) (*MessageRequest, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	return parseMessageRequest(r.Form)
+}
+
+func parseMessageRequest(form url.Values) (*MessageRequest, error) {
+	mr := &MessageRequest{
+		MessageSid:          form.Get(TwiMessageSid),
+		AccountSid:          form.Get(TwiAccountSid),
+		MessagingServiceSid: form.Get(TwiMessagingServiceSid),
+		From:                form.Get(TwiFrom),
+		To:                  form.Get(TwiTo),
+		Body:                form.Get(TwiBody),
+		ApiVersion:          form.Get(TwiApiVersion),
+		OptOutType:          form.Get(TwiOptOutType),
+		FromGeo: Geo{
+			City:    form.Get(TwiFromCity),
+			State:   form.Get(TwiFromState),
+			Zip:     form.Get(TwiFromZip),
+			Country: form.Get(TwiFromCountry),
+		},
+		ToGeo: Geo{
+			City:    form.Get(TwiToCity),
+			State:   form.Get(TwiToState),
+			Zip:     form.Get(TwiToZip),
+			Country: form.Get(TwiToCountry),
+		},
+	}
+	// Older requests only carry the SmsSid
+	if mr.MessageSid == "" {
+		mr.MessageSid = form.Get(TwiSmsSid)
+	}
+	if mr.MessageSid == "" {
+		return nil, fmt.Errorf("required parameter missing: '%s'", TwiMessageSid)
+	}
+	if err := requiredParams(form, TwiAccountSid); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if mr.NumSegments, err = intParam(form, TwiNumSegments); err != nil {
+		return nil, err
+	}
+	numMedia, err := intParam(form, TwiNumMedia)
+	if err != nil {
+		return nil, err
+	}
+	if numMedia < 0 {
+		return nil, invalidParam(TwiNumMedia, form.Get(TwiNumMedia))
+	}
+	for i := 0; i < numMedia; i++ {
+		n := strconv.Itoa(i)
+		if err := requiredParams(form, TwiMediaUrl+n); err != nil {
+			return nil, err
+		}
+		mr.Media = append(mr.Media, Media{
+			Url:         form.Get(TwiMediaUrl + n),
+			ContentType: form.Get(TwiMediaContentType + n),
+		})
+	}
+	return mr, nil
+}
+
+// MediaStore stores media files downloaded from twilio.
+type MediaStore interface {
+	StoreMedia(messageSid string, index int, contentType string, r io.Reader) error
+}
+
+// DirStore is a MediaStore saving each media file in the named directory as
+// {MessageSid}-{index}{extension}.
+type DirStore string
+
+// StoreMedia writes the media read from r to a file in the directory. The
+// message sid must be a valid sid, so the file can't be written elsewhere.
+func (d DirStore) StoreMedia(messageSid string, index int,
+	contentType string, r io.Reader) error {
+
+	if !messageSidPattern.MatchString(messageSid) {
+		return fmt.Errorf("non valid message sid: '%s'", messageSid)
+	}
+	name := fmt.Sprintf("%s-%d", messageSid, index)
+	if exts, _ := mime.ExtensionsByType(contentType); len(exts) > 0 {
+		name += exts[0]
+	}
+	f, err := os.Create(filepath.Join(string(d), name))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// FetchMedia downloads the media attached to an incoming message, using the
+// account credentials, and hands each file to store. Only https urls on one
+// of the MediaHosts are fetched. A nil client uses http.DefaultClient.
+func FetchMedia(client *http.Client, accountSid, authToken string,
+	mr *MessageRequest, store MediaStore) error {
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	for _, m := range mr.Media {
+		if err := mediaURL(m.Url); err != nil {
+			return err
+		}
+	}
+	for i, m := range mr.Media {
+		req, err := http.NewRequest("GET", m.Url, nil)
+		if err != nil {
+			return err
+		}
+		req.SetBasicAuth(accountSid, authToken)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return fmt.Errorf("fetching media %d of %s: %s", i,
+				mr.MessageSid, resp.Status)
+		}
+		contentType := m.ContentType
+		if contentType == "" {
+			contentType = resp.Header.Get("Content-Type")
+		}
+		err = store.StoreMedia(mr.MessageSid, i, contentType, resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mediaURL checks that a media url is a https url on one of the MediaHosts
+func mediaURL(rawurl string) error {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return err
+	}
+	if u.Scheme == "https" && u.User == nil {
+		for _, host := range MediaHosts {
+			if u.Host == host {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("non valid media url: '%s'", rawurl)
+}
@@ -0,0 +1,160 @@
+package twiml_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tmc/twilio/twiml"
+)
+
+const messageSid = "MM0123456789abcdef0123456789abcdef"
+
+func postForm(form url.Values) *http.Request {
+	r := httptest.NewRequest("POST", "/sms", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+func TestParseMessageRequest(t *testing.T) {
+	tests := []struct {
+		name  string
+		form  url.Values
+		sid   string
+		media []twiml.Media
+		err   bool
+	}{
+		{name: "text", form: url.Values{"MessageSid": {messageSid}}, sid: messageSid},
+		{
+			name: "media",
+			form: url.Values{"MessageSid": {messageSid}, "NumMedia": {"2"},
+				"MediaUrl0": {"https://api.twilio.com/m0"}, "MediaContentType0": {"image/png"},
+				"MediaUrl1": {"https://api.twilio.com/m1"}, "MediaContentType1": {"audio/mpeg"}},
+			sid: messageSid,
+			media: []twiml.Media{{Url: "https://api.twilio.com/m0", ContentType: "image/png"},
+				{Url: "https://api.twilio.com/m1", ContentType: "audio/mpeg"}},
+		},
+		{name: "media without content type",
+			form: url.Values{"MessageSid": {messageSid}, "NumMedia": {"1"},
+				"MediaUrl0": {"https://api.twilio.com/m0"}},
+			sid:   messageSid,
+			media: []twiml.Media{{Url: "https://api.twilio.com/m0"}},
+		},
+		{name: "missing media url",
+			form: url.Values{"MessageSid": {messageSid}, "NumMedia": {"2"},
+				"MediaUrl0": {"https://api.twilio.com/m0"}},
+			err: true,
+		},
+		{name: "sms sid", form: url.Values{"SmsSid": {"SM1"}}, sid: "SM1"},
+		{name: "message sid first",
+			form: url.Values{"MessageSid": {messageSid}, "SmsSid": {"SM1"}}, sid: messageSid},
+		{name: "no sid", form: url.Values{}, err: true},
+		{name: "negative num media",
+			form: url.Values{"MessageSid": {messageSid}, "NumMedia": {"-1"}}, err: true},
+		{name: "non numeric num media",
+			form: url.Values{"MessageSid": {messageSid}, "NumMedia": {"two"}}, err: true},
+	}
+	for _, tt := range tests {
+		tt.form.Set("AccountSid", "AC1234567890ABCDE")
+		mr, err := twiml.ParseMessageRequest(postForm(tt.form))
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s: no error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if mr.MessageSid != tt.sid || !reflect.DeepEqual(mr.Media, tt.media) {
+			t.Errorf("%s: parsed %s %+v", tt.name, mr.MessageSid, mr.Media)
+		}
+	}
+}
+
+type memStore map[string]string
+
+func (m memStore) StoreMedia(sid string, index int, contentType string, r io.Reader) error {
+	b, err := io.ReadAll(r)
+	m[contentType] = string(b)
+	return err
+}
+
+func TestFetchMedia(t *testing.T) {
+	var auth []string
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		auth = append(auth, user+":"+pass)
+		w.Header().Set("Content-Type", "image/gif")
+		io.WriteString(w, r.URL.Path)
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "https://")
+
+	hosts := twiml.MediaHosts
+	defer func() { twiml.MediaHosts = hosts }()
+	twiml.MediaHosts = []string{host}
+
+	mr := &twiml.MessageRequest{MessageSid: messageSid, Media: []twiml.Media{
+		{Url: srv.URL + "/m0", ContentType: "image/png"}, {Url: srv.URL + "/m1"}}}
+	store := memStore{}
+	if err := twiml.FetchMedia(srv.Client(), "AC1", "secret", mr, store); err != nil {
+		t.Fatal(err)
+	}
+	if store["image/png"] != "/m0" || store["image/gif"] != "/m1" {
+		t.Errorf("stored %v", store)
+	}
+	if len(auth) != 2 || auth[0] != "AC1:secret" {
+		t.Errorf("credentials %v", auth)
+	}
+
+	for _, u := range []string{
+		"http://" + host + "/m0",         // not https
+		"https://attacker.example/m0",    // other host
+		"https://169.254.169.254/latest", // internal
+		"https://user@" + host + "/m0",
+		"https://" + host + ".attacker.example/m0",
+	} {
+		auth = nil
+		mr.Media = []twiml.Media{{Url: srv.URL + "/m0"}, {Url: u}}
+		if err := twiml.FetchMedia(srv.Client(), "AC1", "secret", mr, memStore{}); err == nil {
+			t.Errorf("fetched %s", u)
+		}
+		if len(auth) != 0 {
+			t.Errorf("%s: requests made before rejecting", u)
+		}
+	}
+}
+
+func TestDirStore(t *testing.T) {
+	dir := t.TempDir()
+	store := twiml.DirStore(filepath.Join(dir, "media"))
+	if err := os.Mkdir(string(store), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.StoreMedia(messageSid, 1, "image/png", strings.NewReader("png")); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join(string(store), messageSid+"-1.png"))
+	if err != nil || string(b) != "png" {
+		t.Errorf("stored %q: %v", b, err)
+	}
+
+	for _, sid := range []string{"../" + messageSid, "../escaped", "MM0123/../../x", "", "MMshort"} {
+		err := store.StoreMedia(sid, 0, "", bytes.NewReader(nil))
+		if err == nil {
+			t.Errorf("stored media of %q", sid)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("files written outside the store: %v", entries)
+	}
+}
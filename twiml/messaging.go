@@ -0,0 +1,177 @@
+package twiml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// MaxMedia is the most Media a Message may carry.
+const MaxMedia = 10
+
+// MessagingResponse is the TwiML reply to an incoming message. Unlike
+// Response it only takes the messaging verbs Message and Redirect, as twilio
+// rejects voice verbs in a messaging document.
+type MessagingResponse struct {
+	XMLName  xml.Name `xml:"Response"`
+	Response []interface{}
+	// ValidateOnSend makes Send fail with the Validate errors of an
+	// invalid response instead of writing it.
+	ValidateOnSend bool `xml:"-"`
+}
+
+// MessagingMessage is the Message verb of a messaging response. Unlike
+// Message it carries up to MaxMedia media urls.
+type MessagingMessage struct {
+	XMLName        xml.Name `xml:"Message"`
+	To             string   `xml:"to,attr,omitempty"`
+	From           string   `xml:"from,attr,omitempty"`
+	Action         string   `xml:"action,attr,omitempty"`
+	Method         string   `xml:"method,attr,omitempty"`
+	StatusCallback string   `xml:"statusCallback,attr,omitempty"`
+	Body           string   `xml:"Body,omitempty"`
+	Media          []string `xml:"Media,omitempty"`
+}
+
+// NewMessagingResponse creates an empty messaging response.
+func NewMessagingResponse() *MessagingResponse {
+	return new(MessagingResponse)
+}
+
+// Action appends messaging verb structs to the response. Valid verbs:
+// Message, MessagingMessage, Redirect
+func (r *MessagingResponse) Action(structs ...interface{}) error {
+	for _, s := range structs {
+		switch s := s.(type) {
+		default:
+			return fmt.Errorf("non valid verb: '%T'", s)
+		case Message, MessagingMessage, Redirect:
+			r.Response = append(r.Response, s)
+		}
+	}
+	return nil
+}
+
+// Send sends xml encoded response to writer. Nothing is written if
+// ValidateOnSend is set and the response is not valid.
+func (r MessagingResponse) Send(w io.Writer) error {
+	if r.ValidateOnSend {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+	}
+	return send(w, r)
+}
+
+// String returns a formatted xml response
+func (r MessagingResponse) String() string {
+	return format(r)
+}
+
+// Validate checks the response against the messaging TwiML rules: only
+// Message and Redirect verbs, Message with a body or media and at most
+// MaxMedia, and no verbs after a Redirect. It returns ValidationErrors holding
+// every problem found, or nil.
+func (r MessagingResponse) Validate() error {
+	v := &validator{}
+	end := ""
+	for _, p := range v.children("Response", r.Response) {
+		if end != "" {
+			v.errorf(p.path, "unreachable after %s", end)
+		}
+		switch e := p.elem.(type) {
+		default:
+			v.errorf(p.path, "not a messaging verb")
+		case Element:
+			v.errorf(p.path, "unknown verb")
+		case Message:
+			v.message(p.path, e)
+		case MessagingMessage:
+			v.messagingMessage(p.path, e)
+		case Redirect:
+			v.method(p.path, "method", e.Method)
+			v.required(p.path, "url", e.Url)
+			if end == "" {
+				end = p.name
+			}
+		}
+	}
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+func (v *validator) messagingMessage(path string, m MessagingMessage) {
+	v.method(path, "method", m.Method)
+	if m.Body == "" && len(m.Media) == 0 {
+		v.errorf(path, "body or media required")
+	}
+	if len(m.Media) > MaxMedia {
+		v.errorf(path, "at most %d media allowed, not %d", MaxMedia, len(m.Media))
+	}
+}
+
+// MessagingBuilder builds a MessagingResponse with typed methods, so that
+// only messaging verbs can be added.
+type MessagingBuilder struct {
+	verbs []builder
+}
+
+// NewMessagingBuilder creates a builder for an empty messaging response.
+func NewMessagingBuilder() *MessagingBuilder {
+	return new(MessagingBuilder)
+}
+
+// Response returns the built response.
+func (b *MessagingBuilder) Response() *MessagingResponse {
+	r := NewMessagingResponse()
+	r.Response = buildAll(b.verbs)
+	return r
+}
+
+// Message appends a Message verb sending body.
+func (b *MessagingBuilder) Message(body string) *MessagingMessageBuilder {
+	m := &MessagingMessageBuilder{v: MessagingMessage{Body: body}}
+	b.verbs = append(b.verbs, m)
+	return m
+}
+
+// Redirect appends a Redirect verb to url.
+func (b *MessagingBuilder) Redirect(url string) *RedirectBuilder {
+	r := &RedirectBuilder{v: Redirect{Url: url}}
+	b.verbs = append(b.verbs, r)
+	return r
+}
+
+// MessagingMessageBuilder sets the attributes and media of a
+// MessagingMessage.
+type MessagingMessageBuilder struct{ v MessagingMessage }
+
+func (m *MessagingMessageBuilder) build() interface{} { return m.v }
+func (m *MessagingMessageBuilder) To(number string) *MessagingMessageBuilder {
+	m.v.To = number
+	return m
+}
+func (m *MessagingMessageBuilder) From(number string) *MessagingMessageBuilder {
+	m.v.From = number
+	return m
+}
+func (m *MessagingMessageBuilder) Action(url string) *MessagingMessageBuilder {
+	m.v.Action = url
+	return m
+}
+func (m *MessagingMessageBuilder) Method(method string) *MessagingMessageBuilder {
+	m.v.Method = method
+	return m
+}
+func (m *MessagingMessageBuilder) StatusCallback(url string) *MessagingMessageBuilder {
+	m.v.StatusCallback = url
+	return m
+}
+
+// Media adds a media url to the message, up to MaxMedia.
+func (m *MessagingMessageBuilder) Media(url string) *MessagingMessageBuilder {
+	m.v.Media = append(m.v.Media, url)
+	return m
+}
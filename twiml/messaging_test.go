@@ -0,0 +1,70 @@
+package twiml_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tmc/twilio/twiml"
+)
+
+func TestMessagingResponse(t *testing.T) {
+	b := twiml.NewMessagingBuilder()
+	b.Message("Your order shipped").Media("https://example.com/1.jpg").
+		Media("https://example.com/2.jpg")
+	resp := b.Response()
+	if err := resp.Validate(); err != nil {
+		t.Error(err)
+	}
+	doc := resp.String()
+	want := "<Body>Your order shipped</Body>\n" +
+		"          <Media>https://example.com/1.jpg</Media>\n" +
+		"          <Media>https://example.com/2.jpg</Media>"
+	if !strings.Contains(doc, want) {
+		t.Errorf("got\n%s\nwant it to contain\n%s", doc, want)
+	}
+
+	if err := resp.Action(twiml.Say{Text: "Hi"}); err == nil {
+		t.Error("Action accepted a voice verb")
+	}
+	resp.Response = append(resp.Response, twiml.Redirect{Url: "/sms"},
+		twiml.Say{Text: "Hi"}, twiml.MessagingMessage{Media: make([]string, 11)})
+	err := resp.Validate()
+	wantPaths := []string{"Response/Say[1]", "Response/Say[1]", "Response/Message[2]",
+		"Response/Message[2]"}
+	if paths := validationPaths(err); !reflect.DeepEqual(paths, wantPaths) {
+		t.Errorf("got errors %v, want paths %v", err, wantPaths)
+	}
+}
+
+func TestParseMessaging(t *testing.T) {
+	doc := `<Response><Message to="+15005550006"><Body>hi</Body><Media>a</Media><Media>b</Media></Message>` +
+		`<Redirect>/sms</Redirect><Say>Hi</Say></Response>`
+	resp, err := twiml.ParseMessagingString(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{
+		twiml.MessagingMessage{To: "+15005550006", Body: "hi", Media: []string{"a", "b"}},
+		twiml.Redirect{Url: "/sms"},
+		twiml.Say{Text: "Hi"},
+	}
+	if !reflect.DeepEqual(resp.Response, want) {
+		t.Errorf("parsed %#v", resp.Response)
+	}
+	if resp.Validate() == nil {
+		t.Error("voice verb in messaging document valid")
+	}
+
+	// a voice document can't hold the media
+	if _, err := twiml.ParseString(doc); err == nil {
+		t.Error("Parse dropped media")
+	}
+	voice, err := twiml.ParseString(`<Response><Message><Body>hi</Body><Media>a</Media></Message></Response>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := voice.Response[0]; !reflect.DeepEqual(m, twiml.Message{Body: "hi", Media: "a"}) {
+		t.Errorf("parsed %#v", m)
+	}
+}
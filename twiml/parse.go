@@ -0,0 +1,383 @@
+package twiml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// Element is a TwiML element without a type in the vocabulary. Parse keeps
+// such elements with their attributes and content so that they marshal back
+// unchanged.
+type Element struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	InnerXML string     `xml:",innerxml"`
+}
+
+// vocabulary creates the struct of each element name Parse knows
+var vocabulary = map[string]func() interface{}{
+	"Client":     func() interface{} { return new(Client) },
+	"Conference": func() interface{} { return new(Conference) },
+	"Connect":    func() interface{} { return new(Connect) },
+	"Dial":       func() interface{} { return new(Dial) },
+	"Enqueue":    func() interface{} { return new(Enqueue) },
+	"Gather":     func() interface{} { return new(Gather) },
+	"Hangup":     func() interface{} { return new(Hangup) },
+	"Leave":      func() interface{} { return new(Leave) },
+	"Message":    func() interface{} { return new(Message) },
+	"Number":     func() interface{} { return new(Number) },
+	"Parameter":  func() interface{} { return new(Parameter) },
+	"Pause":      func() interface{} { return new(Pause) },
+	"Pay":        func() interface{} { return new(Pay) },
+	"Play":       func() interface{} { return new(Play) },
+	"Prompt":     func() interface{} { return new(Prompt) },
+	"Queue":      func() interface{} { return new(Queue) },
+	"Record":     func() interface{} { return new(Record) },
+	"Redirect":   func() interface{} { return new(Redirect) },
+	"Reject":     func() interface{} { return new(Reject) },
+	"Say":        func() interface{} { return new(Say) },
+	"Sip":        func() interface{} { return new(Sip) },
+	"Start":      func() interface{} { return new(Start) },
+	"Stop":       func() interface{} { return new(Stop) },
+	"Stream":     func() interface{} { return new(Stream) },
+}
+
+// ssmlVocabulary creates the struct of each SSML element name Parse knows
+var ssmlVocabulary = map[string]func() interface{}{
+	"amazon:effect": func() interface{} { return new(SSMLAmazonEffect) },
+	"break":         func() interface{} { return new(SSMLBreak) },
+	"emphasis":      func() interface{} { return new(SSMLEmphasis) },
+	"lang":          func() interface{} { return new(SSMLLang) },
+	"p":             func() interface{} { return new(SSMLP) },
+	"phoneme":       func() interface{} { return new(SSMLPhoneme) },
+	"prosody":       func() interface{} { return new(SSMLProsody) },
+	"s":             func() interface{} { return new(SSMLS) },
+	"say-as":        func() interface{} { return new(SSMLSayAs) },
+	"sub":           func() interface{} { return new(SSMLSub) },
+	"w":             func() interface{} { return new(SSMLW) },
+}
+
+// Parse decodes a TwiML document into a Response holding the vocabulary
+// structs of its verbs and nouns, by value and with a zero XMLName, as built
+// by Action, Dial and Gather. Elements the vocabulary has no type for are
+// kept as Element.
+//
+// A Message of a voice document carries a single Media: Parse fails on one
+// with more, which only a messaging document may hold. Use ParseMessaging
+// for those.
+func Parse(r io.Reader) (*Response, error) {
+	verbs, err := parseResponse(r, parseElement)
+	if err != nil {
+		return nil, err
+	}
+	resp := NewResponse()
+	resp.Response = verbs
+	return resp, nil
+}
+
+// ParseString decodes a TwiML document held in a string.
+func ParseString(s string) (*Response, error) {
+	return Parse(strings.NewReader(s))
+}
+
+// ParseMessaging decodes a messaging TwiML document into a
+// MessagingResponse, as Parse does for voice documents. Message verbs are
+// parsed as MessagingMessage, keeping all their Media.
+func ParseMessaging(r io.Reader) (*MessagingResponse, error) {
+	verbs, err := parseResponse(r, parseMessagingElement)
+	if err != nil {
+		return nil, err
+	}
+	resp := NewMessagingResponse()
+	resp.Response = verbs
+	return resp, nil
+}
+
+// ParseMessagingString decodes a messaging TwiML document held in a string.
+func ParseMessagingString(s string) (*MessagingResponse, error) {
+	return ParseMessaging(strings.NewReader(s))
+}
+
+// elementParser parses the element opened by start
+type elementParser func(d *xml.Decoder, start xml.StartElement) (interface{}, error)
+
+// parseResponse parses the verbs of the Response root element with parse
+func parseResponse(r io.Reader, parse elementParser) ([]interface{}, error) {
+	d := xml.NewDecoder(r)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil, fmt.Errorf("no Response element")
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if start.Name.Local != "Response" {
+			return nil, fmt.Errorf("root element is '%s', not Response",
+				start.Name.Local)
+		}
+		verbs, _, err := parseChildren(d, parse)
+		return verbs, err
+	}
+}
+
+// parseMessagingElement parses a verb of a messaging document, a Message as
+// MessagingMessage and other verbs as parseElement does
+func parseMessagingElement(d *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	if start.Name.Local != "Message" {
+		return parseElement(d, start)
+	}
+	m := MessagingMessage{}
+	if err := d.DecodeElement(&m, &start); err != nil {
+		return nil, err
+	}
+	m.XMLName = xml.Name{}
+	return m, nil
+}
+
+// parseChildren parses the elements up to the end of the current element
+// with parse, returning them and the element's trimmed chardata
+func parseChildren(d *xml.Decoder, parse elementParser) ([]interface{}, string, error) {
+	var children []interface{}
+	var text strings.Builder
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, "", err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			child, err := parse(d, tok)
+			if err != nil {
+				return nil, "", err
+			}
+			children = append(children, child)
+		case xml.CharData:
+			text.Write(tok)
+		case xml.EndElement:
+			return children, strings.TrimSpace(text.String()), nil
+		}
+	}
+}
+
+// parseElement parses the element opened by start into its vocabulary
+// struct value
+func parseElement(d *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	newElem, ok := vocabulary[start.Name.Local]
+	if !ok {
+		e := Element{}
+		if err := d.DecodeElement(&e, &start); err != nil {
+			return nil, err
+		}
+		return e, nil
+	}
+	v := newElem()
+
+	switch v := v.(type) {
+	default:
+		if err := d.DecodeElement(v, &start); err != nil {
+			return nil, err
+		}
+	case *Client:
+		if err := d.DecodeElement(v, &start); err != nil {
+			return nil, err
+		}
+		// the name chardata is only indentation next to Identity
+		if v.Identity != "" {
+			v.Name = strings.TrimSpace(v.Name)
+		}
+	case *Message:
+		m := MessagingMessage{}
+		if err := d.DecodeElement(&m, &start); err != nil {
+			return nil, err
+		}
+		if len(m.Media) > 1 {
+			return nil, fmt.Errorf("more than one Media in Message: '%s'",
+				strings.Join(m.Media, "', '"))
+		}
+		*v = Message{To: m.To, From: m.From, Action: m.Action, Method: m.Method,
+			StatusCallback: m.StatusCallback, Body: m.Body}
+		if len(m.Media) == 1 {
+			v.Media = m.Media[0]
+		}
+	case *Enqueue:
+		if err := d.DecodeElement(v, &start); err != nil {
+			return nil, err
+		}
+		// the name chardata is only indentation next to Task
+		if v.Task != nil {
+			v.Name = strings.TrimSpace(v.Name)
+		}
+	case *Connect, *Dial, *Gather, *Pay, *Prompt, *Start, *Stop:
+		// the attributes are decoded from the bare start element, nested
+		// nouns and verbs are parsed one by one
+		if err := decodeAttrs(v, start); err != nil {
+			return nil, err
+		}
+		nested, text, err := parseChildren(d, parseElement)
+		if err != nil {
+			return nil, err
+		}
+		switch v := v.(type) {
+		case *Dial:
+			v.Number = text
+		case *Pay:
+			// Parameter elements are kept apart from the Prompts
+			var prompts []interface{}
+			for _, n := range nested {
+				if p, ok := n.(Parameter); ok {
+					v.Parameters = append(v.Parameters, p)
+				} else {
+					prompts = append(prompts, n)
+				}
+			}
+			nested = prompts
+		}
+		elem := reflect.ValueOf(v).Elem()
+		withNested, _ := WithNested(elem.Interface(), nested)
+		elem.Set(reflect.ValueOf(withNested))
+	case *Say:
+		// SSML is mixed content, kept in order in Nested
+		if err := decodeAttrs(v, start); err != nil {
+			return nil, err
+		}
+		var err error
+		if v.Text, v.Nested, err = parseSSML(d); err != nil {
+			return nil, err
+		}
+	}
+	// clear the decoded name, so parsed values equal the values built in Go
+	elem := reflect.ValueOf(v).Elem()
+	if name := elem.FieldByName("XMLName"); name.IsValid() {
+		name.Set(reflect.ValueOf(xml.Name{}))
+	}
+	return elem.Interface(), nil
+}
+
+// decodeAttrs decodes the attributes of start into v
+func decodeAttrs(v interface{}, start xml.StartElement) error {
+	var b strings.Builder
+	e := xml.NewEncoder(&b)
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	if err := e.EncodeToken(start.End()); err != nil {
+		return err
+	}
+	if err := e.Flush(); err != nil {
+		return err
+	}
+	return xml.Unmarshal([]byte(b.String()), v)
+}
+
+// parseSSML parses the mixed content up to the end of the current element.
+// Content without elements is returned as text. Otherwise the elements are
+// returned with the text between them as SSMLText, whitespace collapsed and
+// trimmed, since marshaling indents elements anyway.
+func parseSSML(d *xml.Decoder) (string, []interface{}, error) {
+	var nested []interface{}
+	var text strings.Builder
+	flush := func() {
+		if t := strings.Join(strings.Fields(text.String()), " "); t != "" {
+			nested = append(nested, SSMLText(t))
+		}
+		text.Reset()
+	}
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return "", nil, err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			flush()
+			e, err := parseSSMLElement(d, tok)
+			if err != nil {
+				return "", nil, err
+			}
+			nested = append(nested, e)
+		case xml.CharData:
+			text.Write(tok)
+		case xml.EndElement:
+			if len(nested) == 0 {
+				return text.String(), nil, nil
+			}
+			flush()
+			return "", nested, nil
+		}
+	}
+}
+
+// parseSSMLElement parses the SSML element opened by start into its struct
+// value
+func parseSSMLElement(d *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	name := start.Name.Local
+	if start.Name.Space == "amazon" {
+		name = "amazon:" + name
+	}
+	newElem, ok := ssmlVocabulary[name]
+	if !ok {
+		e := Element{}
+		if err := d.DecodeElement(&e, &start); err != nil {
+			return nil, err
+		}
+		return e, nil
+	}
+	v := reflect.ValueOf(newElem()).Elem()
+	setAttrs(v, start.Attr)
+
+	if nested := v.FieldByName("Nested"); nested.IsValid() {
+		text, elems, err := parseSSML(d)
+		if err != nil {
+			return nil, err
+		}
+		v.FieldByName("Text").SetString(text)
+		nested.Set(reflect.ValueOf(elems))
+		return v.Interface(), nil
+	}
+	var text strings.Builder
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			return nil, fmt.Errorf("%s can't contain '%s'", name, tok.Name.Local)
+		case xml.CharData:
+			text.Write(tok)
+		case xml.EndElement:
+			if t := v.FieldByName("Text"); t.IsValid() {
+				t.SetString(text.String())
+			}
+			return v.Interface(), nil
+		}
+	}
+}
+
+// setAttrs sets the string attribute fields of the struct v from attrs
+func setAttrs(v reflect.Value, attrs []xml.Attr) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("xml"), ",")
+		if len(tag) < 2 || tag[1] != "attr" {
+			continue
+		}
+		space, local := "", tag[0]
+		if i := strings.Index(local, " "); i >= 0 {
+			space, local = local[:i], local[i+1:]
+		}
+		for _, a := range attrs {
+			if a.Name.Local == local && a.Name.Space == space {
+				v.Field(i).SetString(a.Value)
+			}
+		}
+	}
+}
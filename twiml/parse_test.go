@@ -0,0 +1,231 @@
+package twiml_test
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tmc/twilio/twiml"
+)
+
+func TestParseRoundTrip(t *testing.T) {
+	resp := twiml.NewResponse()
+	resp.Gather(twiml.Gather{Action: "/menu", NumDigits: 1},
+		twiml.Say{Text: "Press 1", Voice: twiml.TwiAlice}, twiml.Pause{Length: 2})
+	resp.Dial(twiml.Dial{CallerId: "+15005550006", Timeout: 10},
+		twiml.Number{Number: "+15005550001", SendDigits: "ww1"},
+		twiml.Client{Name: "jenny"}, twiml.Queue{Name: "support"})
+	resp.Dial(twiml.Dial{Number: "+15005550002"})
+	resp.Action(twiml.Start{Nested: []interface{}{twiml.Stream{Name: "fork",
+		Url: "wss://example.com/audio", Track: twiml.TwiBothTracks}}})
+	resp.Action(twiml.Connect{Nested: []interface{}{twiml.Stream{
+		Url: "wss://example.com/bot", Parameters: []twiml.Parameter{{Name: "lang", Value: "en"}}}}})
+	resp.Action(twiml.Hangup{})
+
+	parsed, err := twiml.ParseString(resp.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.String() != resp.String() {
+		t.Errorf("round trip changed document:\n%s\nwant:\n%s", parsed, resp)
+	}
+	if say := parsed.Response[0].(twiml.Gather).Nested[0]; !reflect.DeepEqual(say, resp.Response[0].(twiml.Gather).Nested[0]) {
+		t.Errorf("parsed %#v", say)
+	}
+	if !reflect.DeepEqual(parsed.Response[4], resp.Response[4]) {
+		t.Errorf("parsed %#v", parsed.Response[4])
+	}
+}
+
+// roundTrip marshals resp and parses it back, checking that its verbs are
+// unchanged, and returns the parsed response
+func roundTrip(t *testing.T, resp *twiml.Response) *twiml.Response {
+	t.Helper()
+	parsed, err := twiml.ParseString(resp.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed.Response, resp.Response) {
+		t.Errorf("parsed %#v\nwant %#v", parsed.Response, resp.Response)
+	}
+	return parsed
+}
+
+func TestParseUnknown(t *testing.T) {
+	doc := `<?xml version="1.0" encoding="UTF-8"?>
+<Response><Say>Hi</Say><Refer action="/refer"><Sip>sip:alice@example.com</Sip></Refer></Response>`
+
+	resp, err := twiml.ParseString(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, ok := resp.Response[1].(twiml.Element)
+	if !ok || e.XMLName.Local != "Refer" {
+		t.Fatalf("unknown element parsed as %#v", resp.Response[1])
+	}
+	want := `<Sip>sip:alice@example.com</Sip>`
+	if e.InnerXML != want {
+		t.Errorf("inner xml %q, want %q", e.InnerXML, want)
+	}
+}
+
+func TestPlayDigits(t *testing.T) {
+	resp, err := twiml.ParseString(`<Response><Play digits="ww1234#"/></Response>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := resp.Response[0].(twiml.Play); !ok || p.Digits != "ww1234#" {
+		t.Errorf("parsed %#v", resp.Response[0])
+	}
+	if err := resp.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	b := twiml.NewBuilder()
+	b.Play("").Digits("w*9")
+	if got := b.Response().String(); !strings.Contains(got, `<Play digits="w*9"></Play>`) {
+		t.Errorf("built %s", got)
+	}
+}
+
+func TestDialAttributes(t *testing.T) {
+	resp := twiml.NewResponse()
+	resp.Dial(twiml.Dial{AnswerOnBridge: twiml.Bool(true), RingTone: "uk", Trim: "trim-silence",
+		Record: "record-from-answer-dual", RecordingStatusCallback: "/recording",
+		RecordingStatusCallbackEvent: "completed"},
+		twiml.Number{Number: "+15005550001", StatusCallback: "/status",
+			StatusCallbackEvent: "initiated answered"},
+		twiml.Client{Identity: "jenny", Parameters: []twiml.Parameter{{Name: "ticket", Value: "42"}}},
+		twiml.Sip{Address: "sip:jenny@example.com", StatusCallbackEvent: "completed"})
+	if err := resp.Validate(); err != nil {
+		t.Error(err)
+	}
+	if want := `record="record-from-answer-dual"`; !strings.Contains(resp.String(), want) {
+		t.Errorf("%s not in\n%s", want, resp)
+	}
+
+	roundTrip(t, resp)
+
+	resp = twiml.NewResponse()
+	resp.Dial(twiml.Dial{Number: "+15005550001", Record: "true"})
+	if err := resp.Validate(); err == nil {
+		t.Error("boolean record is valid")
+	}
+}
+
+func TestOptionalAttributes(t *testing.T) {
+	b := twiml.NewBuilder()
+	b.Play("https://example.com/hold.mp3").Loop(0)
+	b.Record().PlayBeep(false).Timeout(0)
+	resp := b.Response()
+	if err := resp.Validate(); err != nil {
+		t.Error(err)
+	}
+	for _, want := range []string{`<Play loop="0">`, `<Record timeout="0" playBeep="false">`} {
+		if !strings.Contains(resp.String(), want) {
+			t.Errorf("%s not in\n%s", want, resp)
+		}
+	}
+
+	roundTrip(t, resp)
+	if r := twiml.NewResponse(); r.Action(twiml.Play{Url: "a.mp3"}) == nil &&
+		strings.Contains(r.String(), "loop") {
+		t.Errorf("unset loop written: %s", r)
+	}
+}
+
+func TestEnqueueTask(t *testing.T) {
+	task, err := twiml.NewTask(map[string]string{"skill": "billing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	task.Priority = 5
+	resp := twiml.NewResponse()
+	resp.Action(twiml.Enqueue{WorkflowSid: "WW0123456789abcdef0123456789abcdef",
+		Action: "/dequeued", Task: task})
+	if err := resp.Validate(); err != nil {
+		t.Error(err)
+	}
+	if want := `<Task priority="5">`; !strings.Contains(resp.String(), want) {
+		t.Errorf("%s not in\n%s", want, resp)
+	}
+
+	parsed := roundTrip(t, resp)
+	var attrs struct{ Skill string }
+	if err := parsed.Response[0].(twiml.Enqueue).Task.Decode(&attrs); err != nil || attrs.Skill != "billing" {
+		t.Errorf("decoded attributes %+v: %v", attrs, err)
+	}
+
+	resp = twiml.NewResponse()
+	resp.Action(twiml.Enqueue{Name: "support", Task: &twiml.Task{Attributes: "[1]"}})
+	if err := resp.Validate(); err == nil {
+		t.Error("task without workflowSid is valid")
+	}
+}
+
+func TestConferenceAttributes(t *testing.T) {
+	b := twiml.NewBuilder()
+	b.Dial().Conference("support").Coach("CA0123456789abcdef0123456789abcdef").
+		ParticipantLabel("supervisor").Muted(false).
+		StatusCallback("/conference").
+		StatusCallbackEvent(twiml.TwiConferenceJoin, twiml.TwiConferenceLeave,
+			twiml.TwiConferenceSpeaker).
+		Record("record-from-start").RecordingStatusCallback("/recording").
+		Region("ie1").JitterBufferSize("small")
+	resp := b.Response()
+	if err := resp.Validate(); err != nil {
+		t.Error(err)
+	}
+	if want := `statusCallbackEvent="join leave speaker"`; !strings.Contains(resp.String(), want) {
+		t.Errorf("%s not in\n%s", want, resp)
+	}
+
+	roundTrip(t, resp)
+
+	resp = twiml.NewResponse()
+	resp.Dial(twiml.Dial{}, twiml.Conference{Name: "support", Coach: "agent",
+		StatusCallbackEvent: twiml.ConferenceEvents{"ringing"}})
+	if err, ok := resp.Validate().(twiml.ValidationErrors); !ok || len(err) != 3 {
+		t.Errorf("Validate returned %v", err)
+	}
+}
+
+func TestNested(t *testing.T) {
+	say := twiml.Say{Text: "Hi"}
+	for _, e := range []interface{}{twiml.Connect{}, twiml.Dial{}, twiml.Gather{},
+		twiml.Pay{}, twiml.Prompt{}, twiml.Say{}, twiml.Start{}, twiml.Stop{}} {
+		withNested, ok := twiml.WithNested(e, []interface{}{say})
+		if !ok {
+			t.Errorf("%s: no nested elements", twiml.ElementName(e))
+			continue
+		}
+		if n := twiml.Nested(withNested); len(n) != 1 || !reflect.DeepEqual(n[0], say) {
+			t.Errorf("%s: nested %v", twiml.ElementName(e), n)
+		}
+		if reflect.TypeOf(withNested) != reflect.TypeOf(e) {
+			t.Errorf("WithNested returned a %T for a %T", withNested, e)
+		}
+	}
+	if _, ok := twiml.WithNested(twiml.Hangup{}, []interface{}{say}); ok {
+		t.Error("Hangup took nested elements")
+	}
+	if twiml.Nested(nil) != nil || twiml.Nested(twiml.Play{}) != nil {
+		t.Error("nested elements without any")
+	}
+
+	for _, tt := range []struct {
+		elem interface{}
+		want string
+	}{
+		{nil, "<nil>"},
+		{twiml.Say{}, "Say"},
+		{twiml.SSMLBreak{}, "break"},
+		{twiml.Parameter{}, "Parameter"},
+		{twiml.Element{XMLName: xml.Name{Local: "Refer"}}, "Refer"},
+	} {
+		if got := twiml.ElementName(tt.elem); got != tt.want {
+			t.Errorf("ElementName(%#v) = %q, want %q", tt.elem, got, tt.want)
+		}
+	}
+}
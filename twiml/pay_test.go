@@ -0,0 +1,44 @@
+package twiml_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tmc/twilio/twiml"
+)
+
+func TestPay(t *testing.T) {
+	b := twiml.NewBuilder()
+	p := b.Pay("Stripe_Connector").ChargeAmount("10.50", "usd").Action("/paid").
+		ValidCardTypes("visa mastercard").Parameter("description", "invoice 42")
+	p.Prompt(twiml.TwiPayCardNumber).Say("Please enter your card number.")
+	p.Prompt(twiml.TwiPayCardNumber).Attempt("2 3").ErrorType("invalid-card-number").
+		Say("That card number was not valid.")
+	resp := b.Response()
+	if err := resp.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	roundTrip(t, resp)
+}
+
+func TestValidatePay(t *testing.T) {
+	resp := twiml.NewResponse()
+	resp.Action(twiml.Pay{ChargeAmount: "ten", TokenType: twiml.TwiReusable,
+		MaxAttempts: 5, ValidCardTypes: "visa cash",
+		Nested:     []interface{}{twiml.Prompt{For: "pin", Nested: []interface{}{twiml.Gather{}}}},
+		Parameters: []twiml.Parameter{{Value: "x"}}})
+	err := resp.Validate()
+	want := []string{
+		"Response/Pay[1]",
+		"Response/Pay[1]",
+		"Response/Pay[1]",
+		"Response/Pay[1]",
+		"Response/Pay[1]/Prompt[1]",
+		"Response/Pay[1]/Prompt[1]/Gather[1]",
+		"Response/Pay[1]",
+	}
+	if paths := validationPaths(err); !reflect.DeepEqual(paths, want) {
+		t.Errorf("got errors %v, want paths %v", err, want)
+	}
+}
@@ -0,0 +1,233 @@
+package twiml
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// CallStatus is the state of a call as reported by twilio in voice requests
+// and call status callbacks.
+type CallStatus string
+
+// Valid reports whether s is one of the call statuses known to twilio.
+func (s CallStatus) Valid() bool {
+	switch s {
+	case TwiQueued, TwiRinging, TwiInProgress, TwiCompleted, TwiBusy,
+		TwiFailed, TwiNoAnswer, TwiCanceled:
+		return true
+	}
+	return false
+}
+
+// Direction is the direction of a call.
+type Direction string
+
+// Valid reports whether d is one of the call directions known to twilio.
+func (d Direction) Valid() bool {
+	switch d {
+	case TwiInbound, TwiOutboundApi, TwiOutboundDial:
+		return true
+	}
+	return false
+}
+
+// AnsweredBy is the result of answering machine detection.
+type AnsweredBy string
+
+// Valid reports whether a is one of the answering machine detection results
+// known to twilio.
+func (a AnsweredBy) Valid() bool {
+	switch a {
+	case TwiHuman, TwiMachineStart, TwiMachineEndBeep, TwiMachineEndSilence,
+		TwiMachineEndOther, TwiFax, TwiUnknown:
+		return true
+	}
+	return false
+}
+
+// Geo holds the geographic data twilio looks up for a phone number.
+type Geo struct {
+	City    string
+	State   string
+	Zip     string
+	Country string
+}
+
+// VoiceRequest holds the parameters twilio sends with a voice request to the
+// application Url and with call status callbacks.
+type VoiceRequest struct {
+	CallSid       string
+	AccountSid    string
+	From          string
+	To            string
+	CallStatus    CallStatus
+	ApiVersion    string
+	Direction     Direction
+	ForwardedFrom string
+	CallerName    string
+	ParentCallSid string
+	FromGeo       Geo
+	ToGeo         Geo
+	// Status callback parameters
+	CallDuration      time.Duration
+	RecordingUrl      string
+	RecordingSid      string
+	RecordingDuration time.Duration
+	Timestamp         time.Time
+	SequenceNumber    int
+	CallbackSource    string
+	// Answering machine detection, Gather and Record input
+	AnsweredBy   AnsweredBy
+	Digits       string
+	SpeechResult string
+	Confidence   float64
+}
+
+// ParseVoiceRequest parses the form of a twilio voice request. CallSid and
+// AccountSid are required, all enumerated and numeric parameters are
+// validated when present.
+func ParseVoiceRequest(r *http.Request) (*VoiceRequest, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	return parseVoiceRequest(r.Form)
+}
+
+func parseVoiceRequest(form url.Values) (*VoiceRequest, error) {
+	vr := &VoiceRequest{
+		CallSid:       form.Get(TwiCallSid),
+		AccountSid:    form.Get(TwiAccountSid),
+		From:          form.Get(TwiFrom),
+		To:            form.Get(TwiTo),
+		CallStatus:    CallStatus(form.Get(TwiCallStatus)),
+		ApiVersion:    form.Get(TwiApiVersion),
+		Direction:     Direction(form.Get(TwiDirection)),
+		ForwardedFrom: form.Get(TwiForwardedFrom),
+		CallerName:    form.Get(TwiCallerName),
+		ParentCallSid: form.Get(TwiParentCallSid),
+		FromGeo: Geo{
+			City:    form.Get(TwiFromCity),
+			State:   form.Get(TwiFromState),
+			Zip:     form.Get(TwiFromZip),
+			Country: form.Get(TwiFromCountry),
+		},
+		ToGeo: Geo{
+			City:    form.Get(TwiToCity),
+			State:   form.Get(TwiToState),
+			Zip:     form.Get(TwiToZip),
+			Country: form.Get(TwiToCountry),
+		},
+		RecordingUrl:   form.Get(TwiRecordingUrl),
+		RecordingSid:   form.Get(TwiRecordingSid),
+		CallbackSource: form.Get(TwiCallbackSource),
+		AnsweredBy:     AnsweredBy(form.Get(TwiAnsweredBy)),
+		Digits:         form.Get(TwiDigits),
+		SpeechResult:   form.Get(TwiSpeechResult),
+	}
+
+	if err := requiredParams(form, TwiCallSid, TwiAccountSid); err != nil {
+		return nil, err
+	}
+	if vr.CallStatus != "" && !vr.CallStatus.Valid() {
+		return nil, invalidParam(TwiCallStatus, string(vr.CallStatus))
+	}
+	if vr.Direction != "" && !vr.Direction.Valid() {
+		return nil, invalidParam(TwiDirection, string(vr.Direction))
+	}
+	if vr.AnsweredBy != "" && !vr.AnsweredBy.Valid() {
+		return nil, invalidParam(TwiAnsweredBy, string(vr.AnsweredBy))
+	}
+
+	var err error
+	if vr.CallDuration, err = secondsParam(form, TwiCallDuration); err != nil {
+		return nil, err
+	}
+	if vr.RecordingDuration, err = secondsParam(form, TwiRecordingDuration); err != nil {
+		return nil, err
+	}
+	if vr.Timestamp, err = timeParam(form, TwiTimestamp); err != nil {
+		return nil, err
+	}
+	if vr.SequenceNumber, err = intParam(form, TwiSequenceNumber); err != nil {
+		return nil, err
+	}
+	if vr.Confidence, err = floatParam(form, TwiConfidence); err != nil {
+		return nil, err
+	}
+	return vr, nil
+}
+
+// requiredParams checks that the named parameters are present in form
+func requiredParams(form url.Values, names ...string) error {
+	for _, name := range names {
+		if form.Get(name) == "" {
+			return fmt.Errorf("required parameter missing: '%s'", name)
+		}
+	}
+	return nil
+}
+
+func invalidParam(name, value string) error {
+	return fmt.Errorf("non valid %s: '%s'", name, value)
+}
+
+// secondsParam parses an optional parameter holding a number of seconds
+func secondsParam(form url.Values, name string) (time.Duration, error) {
+	n, err := intParam(form, name)
+	return time.Duration(n) * time.Second, err
+}
+
+// intParam parses an optional integer parameter
+func intParam(form url.Values, name string) (int, error) {
+	v := form.Get(name)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, invalidParam(name, v)
+	}
+	return n, nil
+}
+
+// boolParam parses an optional "true" or "false" parameter
+func boolParam(form url.Values, name string) (bool, error) {
+	switch v := form.Get(name); v {
+	case "", "false":
+		return false, nil
+	case "true":
+		return true, nil
+	default:
+		return false, invalidParam(name, v)
+	}
+}
+
+// floatParam parses an optional decimal parameter
+func floatParam(form url.Values, name string) (float64, error) {
+	v := form.Get(name)
+	if v == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, invalidParam(name, v)
+	}
+	return f, nil
+}
+
+// timeParam parses an optional RFC 1123 time parameter, the format twilio
+// uses for timestamps in callbacks
+func timeParam(form url.Values, name string) (time.Time, error) {
+	v := form.Get(name)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC1123Z, v)
+	if err != nil {
+		return time.Time{}, invalidParam(name, v)
+	}
+	return t, nil
+}
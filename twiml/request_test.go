@@ -0,0 +1,160 @@
+package twiml_test
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/tmc/twilio/twiml"
+)
+
+func ExampleParseVoiceRequest() {
+	form := url.Values{
+		"CallSid":      {"CA1234567890ABCDE"},
+		"AccountSid":   {"AC1234567890ABCDE"},
+		"From":         {"+15005550001"},
+		"CallStatus":   {"completed"},
+		"CallDuration": {"42"},
+		"ToCountry":    {"US"},
+	}
+	r := httptest.NewRequest("POST", "/voice", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	req, err := twiml.ParseVoiceRequest(r)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(req.From, req.CallStatus == twiml.TwiCompleted, req.CallDuration, req.ToGeo.Country)
+	// output:
+	// +15005550001 true 42s US
+}
+
+func ExampleParseGatherRequest() {
+	form := url.Values{
+		"CallSid":      {"CA1234567890ABCDE"},
+		"AccountSid":   {"AC1234567890ABCDE"},
+		"SpeechResult": {"Talk to sales."},
+		"Confidence":   {"0.92"},
+	}
+	r := httptest.NewRequest("POST", "/menu", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	req, err := twiml.ParseGatherRequest(r)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(req.SpeechResult, req.Confidence, req.Empty())
+	// output:
+	// Talk to sales. 0.92 false
+}
+
+func ExampleParsePayRequest() {
+	form := url.Values{
+		"CallSid":           {"CA1234567890ABCDE"},
+		"AccountSid":        {"AC1234567890ABCDE"},
+		"Result":            {"success"},
+		"PaymentToken":      {"tok_1234"},
+		"PaymentCardNumber": {"xxxx-xxxx-xxxx-1111"},
+		"PaymentCardType":   {"visa"},
+	}
+	r := httptest.NewRequest("POST", "/paid", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	req, err := twiml.ParsePayRequest(r)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(req.Succeeded(), req.PaymentToken, req.PaymentCardType, req.PaymentCardNumber)
+	// output:
+	// true tok_1234 visa xxxx-xxxx-xxxx-1111
+}
+
+func ExampleParsePayStatusRequest() {
+	form := url.Values{
+		"CallSid":       {"CA1234567890ABCDE"},
+		"AccountSid":    {"AC1234567890ABCDE"},
+		"For":           {"payment-card-number"},
+		"Attempt":       {"2"},
+		"ErrorType":     {"invalid-card-number"},
+		"PartialResult": {"false"},
+		"Required":      {"payment-card-number,expiration-date,security-code"},
+	}
+	r := httptest.NewRequest("POST", "/pay-status", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	req, err := twiml.ParsePayStatusRequest(r)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(req.For, req.Attempt, req.ErrorType, req.Required)
+	// output:
+	// payment-card-number 2 invalid-card-number [payment-card-number expiration-date security-code]
+}
+
+func ExampleParseEnqueueRequest() {
+	form := url.Values{
+		"CallSid":     {"CA1234567890ABCDE"},
+		"AccountSid":  {"AC1234567890ABCDE"},
+		"QueueResult": {"bridged"},
+		"QueueSid":    {"QU1234567890ABCDE"},
+		"QueueTime":   {"95"},
+	}
+	r := httptest.NewRequest("POST", "/dequeued", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	req, err := twiml.ParseEnqueueRequest(r)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(req.QueueResult == twiml.TwiBridged, req.QueueSid, req.QueueTime)
+	// output:
+	// true QU1234567890ABCDE 1m35s
+}
+
+// voiceForm returns the form of a valid voice request with params set, an
+// empty value removing the parameter
+func voiceForm(params map[string]string) url.Values {
+	form := url.Values{
+		"CallSid":    {"CA1234567890ABCDE"},
+		"AccountSid": {"AC1234567890ABCDE"},
+		"CallStatus": {"in-progress"},
+		"Direction":  {"inbound"},
+	}
+	for k, v := range params {
+		if v == "" {
+			form.Del(k)
+		} else {
+			form.Set(k, v)
+		}
+	}
+	return form
+}
+
+func TestParseVoiceRequestErrors(t *testing.T) {
+	tests := []struct {
+		params map[string]string
+		err    string
+	}{
+		{map[string]string{"CallSid": ""}, "required parameter missing: 'CallSid'"},
+		{map[string]string{"AccountSid": ""}, "required parameter missing: 'AccountSid'"},
+		{map[string]string{"CallStatus": "answered"}, "non valid CallStatus: 'answered'"},
+		{map[string]string{"Direction": "outbound"}, "non valid Direction: 'outbound'"},
+		{map[string]string{"AnsweredBy": "robot"}, "non valid AnsweredBy: 'robot'"},
+		{map[string]string{"CallDuration": "4.2"}, "non valid CallDuration: '4.2'"},
+		{map[string]string{"RecordingDuration": "ten"}, "non valid RecordingDuration: 'ten'"},
+		{map[string]string{"Timestamp": "2026-10-19T10:00:00Z"}, "non valid Timestamp: '2026-10-19T10:00:00Z'"},
+		{map[string]string{"SequenceNumber": "first"}, "non valid SequenceNumber: 'first'"},
+		{map[string]string{"Confidence": "high"}, "non valid Confidence: 'high'"},
+	}
+	if _, err := twiml.ParseVoiceRequest(postForm(voiceForm(nil))); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		_, err := twiml.ParseVoiceRequest(postForm(voiceForm(tt.params)))
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("%v: error %v, want %s", tt.params, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package twiml
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+)
+
+// SSML elements nest in Say, e.g. to read a confirmation code digit by digit:
+//
+//	twiml.Say{Voice: "Polly.Joanna", Nested: []interface{}{
+//		twiml.SSMLText("Your code is "),
+//		twiml.SSMLSayAs{InterpretAs: "digits", Text: "4711"},
+//	}}
+//
+// Elements that may hold other elements speak their Text first, followed by
+// Nested. Twilio speaks SSML with Polly and Google voices only.
+
+// SSMLText is text between the elements in Nested. It is escaped when
+// marshaled.
+type SSMLText string
+
+// MarshalXML writes t as chardata.
+func (t SSMLText) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeToken(xml.CharData(t))
+}
+
+// SSMLBreak is a pause of a strength or a time, e.g. "500ms".
+type SSMLBreak struct {
+	XMLName  xml.Name `xml:"break"`
+	Strength string   `xml:"strength,attr,omitempty"`
+	Time     string   `xml:"time,attr,omitempty"`
+}
+
+// SSMLEmphasis speaks its content with a stronger or weaker emphasis.
+type SSMLEmphasis struct {
+	XMLName xml.Name `xml:"emphasis"`
+	Level   string   `xml:"level,attr,omitempty"`
+	Text    string   `xml:",chardata"`
+	Nested  []interface{}
+}
+
+// SSMLLang speaks its content in another language, e.g. "fr-FR".
+type SSMLLang struct {
+	XMLName xml.Name `xml:"lang"`
+	Lang    string   `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	Text    string   `xml:",chardata"`
+	Nested  []interface{}
+}
+
+// SSMLP is a paragraph, followed by a longer pause.
+type SSMLP struct {
+	XMLName xml.Name `xml:"p"`
+	Text    string   `xml:",chardata"`
+	Nested  []interface{}
+}
+
+// SSMLPhoneme speaks its text as the phonetic pronunciation Ph.
+type SSMLPhoneme struct {
+	XMLName  xml.Name `xml:"phoneme"`
+	Alphabet string   `xml:"alphabet,attr,omitempty"`
+	Ph       string   `xml:"ph,attr"`
+	Text     string   `xml:",chardata"`
+}
+
+// SSMLProsody changes the volume, rate or pitch of its content.
+type SSMLProsody struct {
+	XMLName xml.Name `xml:"prosody"`
+	Volume  string   `xml:"volume,attr,omitempty"`
+	Rate    string   `xml:"rate,attr,omitempty"`
+	Pitch   string   `xml:"pitch,attr,omitempty"`
+	Text    string   `xml:",chardata"`
+	Nested  []interface{}
+}
+
+// SSMLS is a sentence, followed by a pause.
+type SSMLS struct {
+	XMLName xml.Name `xml:"s"`
+	Text    string   `xml:",chardata"`
+	Nested  []interface{}
+}
+
+// SSMLSayAs speaks its text as a type of value, e.g. digits or a date.
+type SSMLSayAs struct {
+	XMLName     xml.Name `xml:"say-as"`
+	InterpretAs string   `xml:"interpret-as,attr"`
+	Format      string   `xml:"format,attr,omitempty"`
+	Text        string   `xml:",chardata"`
+}
+
+// SSMLSub speaks Alias in place of its text, e.g. for an abbreviation.
+type SSMLSub struct {
+	XMLName xml.Name `xml:"sub"`
+	Alias   string   `xml:"alias,attr"`
+	Text    string   `xml:",chardata"`
+}
+
+// SSMLW speaks a word with the pronunciation of a part of speech.
+type SSMLW struct {
+	XMLName xml.Name `xml:"w"`
+	Role    string   `xml:"role,attr"`
+	Text    string   `xml:",chardata"`
+}
+
+// SSMLAmazonEffect applies a Polly voice effect such as "whispered".
+type SSMLAmazonEffect struct {
+	XMLName xml.Name `xml:"amazon:effect"`
+	Name    string   `xml:"name,attr"`
+	Text    string   `xml:",chardata"`
+	Nested  []interface{}
+}
+
+// PlainText returns the text of a Say without SSML markup, as a caller
+// hears it: sub elements are replaced by their alias, elements separate
+// words and whitespace is collapsed.
+func (s Say) PlainText() string {
+	words := strings.Join(strings.Fields(s.Text+" "+plainText(s.Nested)), " ")
+	return punctuation.Replace(words)
+}
+
+// punctuation removes the space left between a word and its punctuation
+var punctuation = strings.NewReplacer(" .", ".", " ,", ",", " ;", ";",
+	" :", ":", " !", "!", " ?", "?")
+
+func plainText(nested []interface{}) string {
+	var b strings.Builder
+	for _, n := range nested {
+		b.WriteString(" ")
+		switch n := n.(type) {
+		case SSMLText:
+			b.WriteString(string(n))
+		case SSMLSub:
+			b.WriteString(n.Alias)
+		default:
+			v := reflect.ValueOf(n)
+			if v.Kind() != reflect.Struct {
+				continue
+			}
+			if text := v.FieldByName("Text"); text.IsValid() {
+				b.WriteString(text.String())
+			}
+			if nested := v.FieldByName("Nested"); nested.IsValid() {
+				b.WriteString(plainText(nested.Interface().([]interface{})))
+			}
+		}
+	}
+	return b.String()
+}
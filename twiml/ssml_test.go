@@ -0,0 +1,94 @@
+package twiml_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tmc/twilio/twiml"
+)
+
+func TestSSML(t *testing.T) {
+	say := twiml.Say{Voice: "Polly.Joanna", Nested: []interface{}{
+		twiml.SSMLText("Your code for R&D is"),
+		twiml.SSMLSayAs{InterpretAs: "digits", Text: "4711"},
+		twiml.SSMLBreak{Time: "500ms"},
+		twiml.SSMLProsody{Rate: "90%", Nested: []interface{}{
+			twiml.SSMLText("call"),
+			twiml.SSMLSub{Alias: "support", Text: "sup"},
+		}},
+		twiml.SSMLAmazonEffect{Name: "whispered", Text: "bye"},
+		twiml.SSMLLang{Lang: "fr-FR", Text: "au revoir"},
+	}}
+	resp := twiml.NewResponse()
+	resp.Action(say)
+
+	doc := resp.String()
+	for _, want := range []string{"R&amp;D", `<say-as interpret-as="digits">4711</say-as>`,
+		`<amazon:effect name="whispered">`, `<lang xml:lang="fr-FR">`} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("document lacks %s:\n%s", want, doc)
+		}
+	}
+	if err := resp.Validate(); err != nil {
+		t.Error(err)
+	}
+	if got, want := say.PlainText(), "Your code for R&D is 4711 call support bye au revoir"; got != want {
+		t.Errorf("PlainText is %q, want %q", got, want)
+	}
+
+	parsed, err := twiml.ParseString(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := parsed.Response[0].(twiml.Say)
+	if !reflect.DeepEqual(got.Nested, say.Nested) {
+		t.Errorf("parsed %#v", got.Nested)
+	}
+	if parsed.String() != doc {
+		t.Errorf("round trip changed document:\n%s\nwant:\n%s", parsed, doc)
+	}
+}
+
+func TestSayBuilderSSML(t *testing.T) {
+	b := twiml.NewBuilder()
+	b.Say("Your code is").Voice("Polly.Joanna").
+		SayAs("digits", "4711").Break("500ms").Emphasis("strong", "now").
+		Text("or").SSML(twiml.SSMLProsody{Rate: "90%", Text: "later"})
+
+	want := twiml.NewResponse()
+	want.Action(twiml.Say{Text: "Your code is", Voice: "Polly.Joanna", Nested: []interface{}{
+		twiml.SSMLSayAs{InterpretAs: "digits", Text: "4711"},
+		twiml.SSMLBreak{Time: "500ms"},
+		twiml.SSMLEmphasis{Level: "strong", Text: "now"},
+		twiml.SSMLText("or"),
+		twiml.SSMLProsody{Rate: "90%", Text: "later"},
+	}})
+	if got := b.Response(); !reflect.DeepEqual(got.Response, want.Response) {
+		t.Errorf("built\n%s\nwant\n%s", got, want)
+	}
+	if err := b.Response().Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestValidateSSML(t *testing.T) {
+	resp := twiml.NewResponse()
+	resp.Action(twiml.Say{Voice: twiml.TwiAlice, Nested: []interface{}{
+		twiml.SSMLBreak{Time: "long"},
+		twiml.SSMLS{Nested: []interface{}{twiml.SSMLP{Text: "x"}}},
+		twiml.SSMLSayAs{InterpretAs: "telephone", Format: "mdy"},
+		twiml.Pause{},
+	}})
+	err := resp.Validate()
+	want := []string{
+		"Response/Say[1]",
+		"Response/Say[1]/break[1]",
+		"Response/Say[1]/s[1]/p[1]",
+		"Response/Say[1]/say-as[1]",
+		"Response/Say[1]/Pause[1]",
+	}
+	if paths := validationPaths(err); !reflect.DeepEqual(paths, want) {
+		t.Errorf("got errors %v, want paths %v", err, want)
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io"
+	"reflect"
+	"strings"
 )
 
 // Create new response
@@ -16,15 +18,16 @@ func NewResponse() *Response {
 	return new(Response)
 }
 
-// Action appends action verb structs to response. Valid verbs: Enqueue, Say,
-// Leave, Message, Pause, Play, Record, Redirect, Reject, Hangup
+// Action appends action verb structs to response. Valid verbs: Connect,
+// Enqueue, Say, Leave, Message, Pause, Pay, Play, Record, Redirect, Reject,
+// Hangup, Start, Stop
 func (r *Response) Action(structs ...interface{}) error {
 	for _, s := range structs {
 		switch s := s.(type) {
 		default:
 			return fmt.Errorf("non valid verb: '%T'", s)
-		case Enqueue, Hangup, Leave, Message, Pause, Play, Record,
-			Redirect, Reject, Say:
+		case Connect, Enqueue, Hangup, Leave, Message, Pause, Pay, Play,
+			Record, Redirect, Reject, Say, Start, Stop:
 			r.Response = append(r.Response, s)
 		}
 	}
@@ -41,14 +44,8 @@ func (r *Response) Dial(structs ...interface{}) error {
 		default:
 			return fmt.Errorf("non valid verb: '%T'", s)
 		case Dial:
-			d.HangupOnStar = s.HangupOnStar
-			d.TimeLimit = s.TimeLimit
-			d.CallerId = s.CallerId
-			d.Timeout = s.Timeout
-			d.Action = s.Action
-			d.Method = s.Method
-			d.Record = s.Record
-			d.Number = s.Number
+			s.Nested = d.Nested
+			d = s
 		case Client, Conference, Number, Queue, Sip:
 			d.Nested = append(d.Nested, s)
 		}
@@ -67,11 +64,8 @@ func (r *Response) Gather(structs ...interface{}) error {
 		default:
 			return fmt.Errorf("non valid verb: '%T'", s)
 		case Gather:
-			g.FinishOnKey = s.FinishOnKey
-			g.NumDigits = s.NumDigits
-			g.Timeout = s.Timeout
-			g.Action = s.Action
-			g.Method = s.Method
+			s.Nested = g.Nested
+			g = s
 		case Say, Pause, Play: // Valid nested verbs
 			g.Nested = append(g.Nested, s)
 		}
@@ -81,8 +75,97 @@ func (r *Response) Gather(structs ...interface{}) error {
 	return nil
 }
 
-// Send sends xml encoded response to writer
+// ElementName returns the TwiML element name of a vocabulary struct, e.g.
+// "Say" for a Say or "break" for a SSMLBreak.
+func ElementName(e interface{}) string {
+	if el, ok := e.(Element); ok {
+		return el.XMLName.Local
+	}
+	if t := reflect.TypeOf(e); t != nil && t.Kind() == reflect.Struct {
+		if f, ok := t.FieldByName("XMLName"); ok {
+			if name := strings.Split(f.Tag.Get("xml"), ",")[0]; name != "" {
+				return name
+			}
+		}
+	}
+	name := fmt.Sprintf("%T", e)
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// Nested returns the nouns, verbs or SSML elements nested in a verb, nil for
+// verbs without nested elements.
+func Nested(e interface{}) []interface{} {
+	switch e := e.(type) {
+	case Connect:
+		return e.Nested
+	case Dial:
+		return e.Nested
+	case Gather:
+		return e.Nested
+	case Pay:
+		return e.Nested
+	case Prompt:
+		return e.Nested
+	case Say:
+		return e.Nested
+	case Start:
+		return e.Nested
+	case Stop:
+		return e.Nested
+	}
+	return nil
+}
+
+// WithNested returns a copy of verb e with its nested elements set to nested.
+// It returns false if e has no nested elements.
+func WithNested(e interface{}, nested []interface{}) (interface{}, bool) {
+	switch e := e.(type) {
+	case Connect:
+		e.Nested = nested
+		return e, true
+	case Dial:
+		e.Nested = nested
+		return e, true
+	case Gather:
+		e.Nested = nested
+		return e, true
+	case Pay:
+		e.Nested = nested
+		return e, true
+	case Prompt:
+		e.Nested = nested
+		return e, true
+	case Say:
+		e.Nested = nested
+		return e, true
+	case Start:
+		e.Nested = nested
+		return e, true
+	case Stop:
+		e.Nested = nested
+		return e, true
+	}
+	return e, false
+}
+
+// Send sends xml encoded response to writer. Nothing is written if
+// ValidateOnSend is set and the response is not valid.
 func (r Response) Send(w io.Writer) (err error) {
+	if r.ValidateOnSend {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+	}
+	return send(w, r)
+}
+
+// String returns a formatted xml response
+func (r Response) String() string {
+	return format(r)
+}
+
+// send writes the xml document of a response to w
+func send(w io.Writer, r interface{}) error {
 	enc := xml.NewEncoder(w)
 	enc.Indent("  ", "   ")
 
@@ -91,11 +174,11 @@ func (r Response) Send(w io.Writer) (err error) {
 		return err
 	}
 	fmt.Fprintf(w, "\n")
-	return err
+	return nil
 }
 
-// String returns a formatted xml response
-func (r Response) String() string {
+// format returns the formatted xml document of a response
+func format(r interface{}) string {
 	output, err := xml.MarshalIndent(r, "  ", "    ")
 
 	if err != nil {
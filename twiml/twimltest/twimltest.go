@@ -0,0 +1,276 @@
+// Package twimltest provides utilities for testing TwiML http handlers: it
+// calls a handler with a synthetic twilio request, parses the TwiML it
+// returns, and offers semantic assertions and normalized golden-file
+// comparison, so tests don't break on whitespace or attribute order.
+package twimltest
+
+import (
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tmc/twilio/twiml"
+)
+
+var update = flag.Bool("twimltest.update", false, "update TwiML golden files")
+
+// Default parameters of a synthetic request, kept unless overridden.
+var DefaultParams = url.Values{
+	twiml.TwiCallSid:    {"CA00000000000000000000000000000000"},
+	twiml.TwiAccountSid: {"AC00000000000000000000000000000000"},
+	twiml.TwiFrom:       {"+15005550001"},
+	twiml.TwiTo:         {"+15005550002"},
+	twiml.TwiCallStatus: {twiml.TwiInProgress},
+	twiml.TwiDirection:  {twiml.TwiInbound},
+	twiml.TwiApiVersion: {"2010-04-01"},
+}
+
+// NewRequest returns a POST request to target as twilio makes it, carrying
+// DefaultParams overridden by params.
+func NewRequest(target string, params url.Values) *http.Request {
+	form := url.Values{}
+	for k, v := range DefaultParams {
+		form[k] = v
+	}
+	for k, v := range params {
+		form[k] = v
+	}
+	r := httptest.NewRequest("POST", target, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+// Result is the parsed TwiML a handler returned.
+type Result struct {
+	t        testing.TB
+	Code     int
+	Body     string
+	Response *twiml.Response
+}
+
+// Call calls h with NewRequest(target, params) and parses its response. The
+// test fails immediately if the handler doesn't answer with TwiML.
+func Call(t testing.TB, h http.Handler, target string, params url.Values) *Result {
+	t.Helper()
+	return Do(t, h, NewRequest(target, params))
+}
+
+// Do calls h with r and parses its response. The test fails immediately if
+// the handler doesn't answer with TwiML.
+func Do(t testing.TB, h http.Handler, r *http.Request) *Result {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("%s %s: status %d: %s", r.Method, r.URL, w.Code, w.Body)
+	}
+	resp, err := twiml.ParseString(w.Body.String())
+	if err != nil {
+		t.Fatalf("%s %s: %v\n%s", r.Method, r.URL, err, w.Body)
+	}
+	return &Result{t: t, Code: w.Code, Body: w.Body.String(), Response: resp}
+}
+
+// Find returns the element at path, e.g. "Gather[1]/Say[2]" for the second
+// Say in the first Gather, or nil if there is none. Indexes count elements
+// of the same name from 1 and may be left out for the first one.
+func (r *Result) Find(path string) interface{} {
+	elems := r.Response.Response
+	var found interface{}
+	for _, step := range strings.Split(path, "/") {
+		name, n := step, 1
+		if i := strings.Index(step, "["); i >= 0 && strings.HasSuffix(step, "]") {
+			name = step[:i]
+			var err error
+			if n, err = strconv.Atoi(step[i+1 : len(step)-1]); err != nil {
+				return nil
+			}
+		}
+		found = nil
+		for _, e := range elems {
+			if twiml.ElementName(e) == name {
+				if n--; n == 0 {
+					found = e
+					break
+				}
+			}
+		}
+		if found == nil {
+			return nil
+		}
+		elems = twiml.Nested(found)
+	}
+	return found
+}
+
+// Verbs asserts that the response holds exactly the named verbs, in order.
+func (r *Result) Verbs(names ...string) *Result {
+	r.t.Helper()
+	var got []string
+	for _, e := range r.Response.Response {
+		got = append(got, twiml.ElementName(e))
+	}
+	if strings.Join(got, " ") != strings.Join(names, " ") {
+		r.t.Errorf("verbs are %v, want %v\n%s", got, names, r.Body)
+	}
+	return r
+}
+
+// Say asserts that the element at path is a Say containing text, SSML markup
+// removed.
+func (r *Result) Say(path, text string) *Result {
+	r.t.Helper()
+	if s, ok := r.find(path, "Say").(twiml.Say); ok && !strings.Contains(s.PlainText(), text) {
+		r.t.Errorf("%s says %q, want it to contain %q", path, s.PlainText(), text)
+	}
+	return r
+}
+
+// Play asserts that the element at path is a Play of url.
+func (r *Result) Play(path, url string) *Result {
+	r.t.Helper()
+	if p, ok := r.find(path, "Play").(twiml.Play); ok && p.Url != url {
+		r.t.Errorf("%s plays %q, want %q", path, p.Url, url)
+	}
+	return r
+}
+
+// GatherAction asserts that the element at path is a Gather with action url.
+func (r *Result) GatherAction(path, url string) *Result {
+	r.t.Helper()
+	if g, ok := r.find(path, "Gather").(twiml.Gather); ok && g.Action != url {
+		r.t.Errorf("%s action is %q, want %q", path, g.Action, url)
+	}
+	return r
+}
+
+// Redirect asserts that the element at path is a Redirect to url.
+func (r *Result) Redirect(path, url string) *Result {
+	r.t.Helper()
+	if rd, ok := r.find(path, "Redirect").(twiml.Redirect); ok && rd.Url != url {
+		r.t.Errorf("%s redirects to %q, want %q", path, rd.Url, url)
+	}
+	return r
+}
+
+// Dial asserts that the element at path is a Dial with target among its
+// number, client, sip, conference or queue targets.
+func (r *Result) Dial(path, target string) *Result {
+	r.t.Helper()
+	d, ok := r.find(path, "Dial").(twiml.Dial)
+	if !ok {
+		return r
+	}
+	targets := dialTargets(d)
+	for _, t := range targets {
+		if t == target {
+			return r
+		}
+	}
+	r.t.Errorf("%s dials %q, want %q", path, targets, target)
+	return r
+}
+
+// Valid asserts that the response passes twiml Validate.
+func (r *Result) Valid() *Result {
+	r.t.Helper()
+	if err := r.Response.Validate(); err != nil {
+		r.t.Errorf("invalid TwiML: %v\n%s", err, r.Body)
+	}
+	return r
+}
+
+// Golden compares the normalized response with the golden file at path,
+// reporting a line diff. The file is written instead when the test runs with
+// -twimltest.update.
+func (r *Result) Golden(path string) *Result {
+	r.t.Helper()
+	got := r.Response.String()
+	if *update {
+		if err := ioutil.WriteFile(path, []byte(got), 0644); err != nil {
+			r.t.Fatal(err)
+		}
+		return r
+	}
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		r.t.Fatalf("%v (run with -twimltest.update to create it)", err)
+	}
+	Equal(r.t, got, string(want))
+	return r
+}
+
+// Equal asserts that two TwiML documents are equal once normalized, i.e.
+// parsed and marshaled again, reporting a line diff.
+func Equal(t testing.TB, got, want string) {
+	t.Helper()
+	g, err := Normalize(got)
+	if err != nil {
+		t.Fatalf("got: %v", err)
+	}
+	w, err := Normalize(want)
+	if err != nil {
+		t.Fatalf("want: %v", err)
+	}
+	if g != w {
+		t.Errorf("TwiML differs (-want +got):\n%s", diff(w, g))
+	}
+}
+
+// Normalize parses a TwiML document and marshals it again, giving every
+// document with the same verbs, nouns and attributes the same text.
+func Normalize(doc string) (string, error) {
+	resp, err := twiml.ParseString(doc)
+	if err != nil {
+		return "", err
+	}
+	return resp.String(), nil
+}
+
+// find returns the element at path, failing the test if it is missing or
+// not named name
+func (r *Result) find(path, name string) interface{} {
+	r.t.Helper()
+	e := r.Find(path)
+	if e == nil {
+		r.t.Errorf("no element at %s\n%s", path, r.Body)
+		return nil
+	}
+	if twiml.ElementName(e) != name {
+		r.t.Errorf("%s is a %s, want %s", path, twiml.ElementName(e), name)
+		return nil
+	}
+	return e
+}
+
+// dialTargets lists what a Dial dials
+func dialTargets(d twiml.Dial) []string {
+	var targets []string
+	if d.Number != "" {
+		targets = append(targets, d.Number)
+	}
+	for _, n := range d.Nested {
+		switch n := n.(type) {
+		case twiml.Client:
+			if n.Identity != "" {
+				targets = append(targets, n.Identity)
+			} else {
+				targets = append(targets, n.Name)
+			}
+		case twiml.Conference:
+			targets = append(targets, n.Name)
+		case twiml.Number:
+			targets = append(targets, n.Number)
+		case twiml.Queue:
+			targets = append(targets, n.Name)
+		case twiml.Sip:
+			targets = append(targets, n.Address)
+		}
+	}
+	return targets
+}
@@ -0,0 +1,143 @@
+package twimltest_test
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tmc/twilio/twiml"
+	"github.com/tmc/twilio/twiml/twimltest"
+)
+
+func menu(w http.ResponseWriter, r *http.Request) {
+	resp := twiml.NewResponse()
+	resp.Gather(twiml.Gather{Action: "/menu", NumDigits: 1},
+		twiml.Say{Text: "Welcome to Acme. Press 1 for sales."})
+	resp.Dial(twiml.Dial{}, twiml.Number{Number: "+15005550006"})
+	resp.Send(w)
+}
+
+func TestAssertions(t *testing.T) {
+	twimltest.Call(t, http.HandlerFunc(menu), "/voice", nil).
+		Valid().
+		Verbs("Gather", "Dial").
+		Say("Gather/Say", "Welcome to Acme").
+		GatherAction("Gather[1]", "/menu").
+		Dial("Dial", "+15005550006").
+		Golden("testdata/menu.golden")
+}
+
+func TestEqual(t *testing.T) {
+	twimltest.Equal(t,
+		`<Response><Gather numDigits="1" action="/menu"><Say>Hi</Say></Gather></Response>`,
+		`<?xml version="1.0" encoding="UTF-8"?>
+<Response>
+  <Gather action="/menu" numDigits="1">
+    <Say>Hi</Say>
+  </Gather>
+</Response>`)
+}
+
+// fakeT records the failures of the assertions run against it
+type fakeT struct {
+	testing.TB
+	errors []string
+	fatal  bool
+}
+
+// stopped is panicked by Fatal to stop the assertion, as t.FailNow does
+type stopped struct{}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeT) Fatal(args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprint(args...))
+	f.fatal = true
+	panic(stopped{})
+}
+
+func (f *fakeT) Fatalf(format string, args ...interface{}) {
+	f.Fatal(fmt.Sprintf(format, args...))
+}
+
+// failures runs assert against a fakeT and returns it
+func failures(assert func(t testing.TB)) (f *fakeT) {
+	f = &fakeT{}
+	defer func() {
+		if r := recover(); r != nil && r != (stopped{}) {
+			panic(r)
+		}
+	}()
+	assert(f)
+	return f
+}
+
+func TestAssertionFailures(t *testing.T) {
+	golden := filepath.Join(t.TempDir(), "other.golden")
+	if err := ioutil.WriteFile(golden, []byte("<Response><Hangup/></Response>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	h := http.HandlerFunc(menu)
+	tests := []struct {
+		name   string
+		assert func(t testing.TB)
+		fatal  bool
+		want   string
+	}{
+		{name: "verbs", want: "verbs are [Gather Dial], want [Say]",
+			assert: func(t testing.TB) { twimltest.Call(t, h, "/voice", nil).Verbs("Say") }},
+		{name: "say text", want: `Gather/Say says "Welcome to Acme. Press 1 for sales.", want it to contain "Goodbye"`,
+			assert: func(t testing.TB) { twimltest.Call(t, h, "/voice", nil).Say("Gather/Say", "Goodbye") }},
+		{name: "say missing", want: "no element at Gather/Say[2]",
+			assert: func(t testing.TB) { twimltest.Call(t, h, "/voice", nil).Say("Gather/Say[2]", "Hi") }},
+		{name: "say other verb", want: "Dial is a Dial, want Say",
+			assert: func(t testing.TB) { twimltest.Call(t, h, "/voice", nil).Say("Dial", "Hi") }},
+		{name: "gather action", want: `Gather[1] action is "/menu", want "/other"`,
+			assert: func(t testing.TB) { twimltest.Call(t, h, "/voice", nil).GatherAction("Gather[1]", "/other") }},
+		{name: "dial", want: `Dial dials ["+15005550006"], want "+15005550007"`,
+			assert: func(t testing.TB) { twimltest.Call(t, h, "/voice", nil).Dial("Dial", "+15005550007") }},
+		{name: "valid", want: "invalid TwiML: Response/Play[1]: url or digits required",
+			assert: func(t testing.TB) {
+				twimltest.Call(t, send(`<Response><Play/></Response>`), "/voice", nil).Valid()
+			}},
+		{name: "golden", want: "TwiML differs (-want +got):",
+			assert: func(t testing.TB) { twimltest.Call(t, h, "/voice", nil).Golden(golden) }},
+		{name: "golden missing", fatal: true, want: "run with -twimltest.update to create it",
+			assert: func(t testing.TB) { twimltest.Call(t, h, "/voice", nil).Golden(golden + ".missing") }},
+		{name: "equal", want: "<Say>Hi</Say>\n+",
+			assert: func(t testing.TB) {
+				twimltest.Equal(t, "<Response><Say>Bye</Say></Response>", "<Response><Say>Hi</Say></Response>")
+			}},
+		{name: "equal not twiml", fatal: true, want: "got: ",
+			assert: func(t testing.TB) { twimltest.Equal(t, "<Response>", "<Response/>") }},
+		{name: "status", fatal: true, want: "POST /voice: status 500: broken",
+			assert: func(t testing.TB) {
+				twimltest.Call(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					http.Error(w, "broken", http.StatusInternalServerError)
+				}), "/voice", nil)
+			}},
+		{name: "not twiml", fatal: true, want: "POST /voice: ",
+			assert: func(t testing.TB) { twimltest.Call(t, send("hello"), "/voice", nil) }},
+	}
+	for _, tt := range tests {
+		f := failures(tt.assert)
+		if len(f.errors) != 1 || f.fatal != tt.fatal || !strings.Contains(f.errors[0], tt.want) {
+			t.Errorf("%s: failures %q (fatal %v), want %q", tt.name, f.errors, f.fatal, tt.want)
+		}
+	}
+}
+
+// send returns a handler answering with doc
+func send(doc string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, doc)
+	})
+}
@@ -0,0 +1,619 @@
+package twiml
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ValidationError is a problem found in a TwiML document, located by an
+// element path such as "Response/Gather[1]/Say[2]", counting elements of
+// the same name from 1.
+type ValidationError struct {
+	Path string
+	Msg  string
+}
+
+func (e ValidationError) Error() string {
+	return e.Path + ": " + e.Msg
+}
+
+// ValidationErrors holds all problems found in a TwiML document.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Validate checks the response against the TwiML rules twilio enforces at
+// runtime: which verbs and nouns may be nested where, attribute enumerations
+// and ranges, voice and language compatibility, and verbs made unreachable by
+// Hangup, Reject or Redirect. It returns ValidationErrors holding every
+// problem found, or nil.
+func (r Response) Validate() error {
+	v := &validator{}
+	end := ""
+	for _, p := range v.children("Response", r.Response) {
+		if end != "" {
+			v.errorf(p.path, "unreachable after %s", end)
+		}
+		switch p.elem.(type) {
+		case Hangup, Reject, Redirect:
+			if end == "" {
+				end = p.name
+			}
+		}
+		v.verb(p.path, p.elem)
+	}
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+// validator collects validation errors
+type validator struct {
+	errs ValidationErrors
+}
+
+func (v *validator) errorf(path, format string, args ...interface{}) {
+	v.errs = append(v.errs, ValidationError{path, fmt.Sprintf(format, args...)})
+}
+
+// child is an element with its path
+type child struct {
+	path string
+	name string
+	elem interface{}
+}
+
+// children returns the paths of nested elements below parent
+func (v *validator) children(parent string, elems []interface{}) []child {
+	counts := map[string]int{}
+	cs := make([]child, len(elems))
+	for i, e := range elems {
+		name := ElementName(e)
+		counts[name]++
+		cs[i] = child{fmt.Sprintf("%s/%s[%d]", parent, name, counts[name]), name, e}
+	}
+	return cs
+}
+
+// verb validates a verb nested in Response
+func (v *validator) verb(path string, e interface{}) {
+	switch e := e.(type) {
+	default:
+		v.errorf(path, "not a verb")
+	case Element:
+		v.errorf(path, "unknown verb")
+	case Connect:
+		v.method(path, "method", e.Method)
+		v.streams(path, "Connect", e.Nested)
+	case Dial:
+		v.dial(path, e)
+	case Enqueue:
+		v.enqueue(path, e)
+	case Gather:
+		v.gather(path, e)
+	case Hangup, Leave:
+	case Message:
+		v.message(path, e)
+	case Pause:
+		v.min(path, "length", e.Length, 0)
+	case Pay:
+		v.pay(path, e)
+	case Play:
+		v.play(path, e)
+	case Record:
+		v.method(path, "method", e.Method)
+		v.optMin(path, "timeout", e.Timeout, 0)
+		v.min(path, "maxLength", e.MaxLength, 0)
+		v.finishOnKey(path, e.FinishOnKey)
+	case Redirect:
+		v.method(path, "method", e.Method)
+		v.required(path, "url", e.Url)
+	case Reject:
+		v.enum(path, "reason", e.Reason, "rejected", "busy")
+	case Say:
+		v.say(path, e)
+	case Start:
+		v.method(path, "method", e.Method)
+		v.streams(path, "Start", e.Nested)
+	case Stop:
+		v.streams(path, "Stop", e.Nested)
+	}
+}
+
+// ringTones lists the countries whose ringback tone Dial can play
+var ringTones = []string{"at", "au", "bg", "br", "be", "ch", "cl", "cn",
+	"cz", "de", "dk", "ee", "es", "fi", "fr", "gr", "hu", "il", "in", "it",
+	"lt", "jp", "mx", "my", "nl", "no", "nz", "ph", "pl", "pt", "ru", "se",
+	"sg", "th", "uk", "us", "us-old", "tw", "ve", "za"}
+
+// callEvents are the statusCallbackEvent values of Dial nouns
+var callEvents = []string{"initiated", "ringing", "answered", "completed"}
+
+func (v *validator) dial(path string, d Dial) {
+	v.method(path, "method", d.Method)
+	v.between(path, "timeout", d.Timeout, 5, 600)
+	v.between(path, "timeLimit", d.TimeLimit, 0, 14400)
+	v.enum(path, "ringTone", d.RingTone, ringTones...)
+	v.enum(path, "record", d.Record, "do-not-record", "record-from-answer",
+		"record-from-ringing", "record-from-answer-dual", "record-from-ringing-dual")
+	v.method(path, "recordingStatusCallbackMethod", d.RecordingStatusCallbackMethod)
+	v.events(path, "recordingStatusCallbackEvent", d.RecordingStatusCallbackEvent,
+		"in-progress", "completed", "absent")
+	v.enum(path, "recordingTrack", d.RecordingTrack, "both", "inbound", "outbound")
+	v.enum(path, "trim", d.Trim, "trim-silence", "do-not-trim")
+	v.method(path, "referMethod", d.ReferMethod)
+	if d.Number != "" && len(d.Nested) > 0 {
+		v.errorf(path, "number chardata and nested nouns are exclusive")
+	}
+	if d.Number == "" && len(d.Nested) == 0 {
+		v.errorf(path, "number or nested noun required")
+	}
+	for _, c := range v.children(path, d.Nested) {
+		switch n := c.elem.(type) {
+		default:
+			v.errorf(c.path, "not a Dial noun")
+		case Client:
+			v.method(c.path, "method", n.Method)
+			v.callbacks(c.path, n.StatusCallbackMethod, n.StatusCallbackEvent)
+			switch {
+			case n.Name != "" && n.Identity != "":
+				v.errorf(c.path, "name chardata and identity are exclusive")
+			case n.Name == "" && n.Identity == "":
+				v.errorf(c.path, "client name required")
+			case len(n.Parameters) > 0 && n.Identity == "":
+				v.errorf(c.path, "parameters require identity")
+			}
+			for _, p := range n.Parameters {
+				v.required(c.path, "parameter name", p.Name)
+			}
+		case Conference:
+			v.conference(c.path, n)
+			v.alone(c.path, d.Nested)
+		case Number:
+			v.method(c.path, "method", n.Method)
+			v.callbacks(c.path, n.StatusCallbackMethod, n.StatusCallbackEvent)
+			v.required(c.path, "number", n.Number)
+		case Queue:
+			v.method(c.path, "method", n.Method)
+			v.required(c.path, "queue name", n.Name)
+			v.alone(c.path, d.Nested)
+		case Sip:
+			v.method(c.path, "method", n.Method)
+			v.callbacks(c.path, n.StatusCallbackMethod, n.StatusCallbackEvent)
+			if !strings.HasPrefix(strings.ToLower(n.Address), "sip:") {
+				v.errorf(c.path, "sip address must start with 'sip:'")
+			}
+		}
+	}
+}
+
+// streams validates the Stream nested in a Connect, Start or Stop
+func (v *validator) streams(path, parent string, nested []interface{}) {
+	if len(nested) != 1 {
+		v.errorf(path, "one nested noun required")
+	}
+	for _, c := range v.children(path, nested) {
+		switch n := c.elem.(type) {
+		default:
+			v.errorf(c.path, "not allowed in %s", parent)
+		case Element:
+			v.errorf(c.path, "unknown noun")
+		case Stream:
+			v.stream(c.path, parent, n)
+		}
+	}
+}
+
+func (v *validator) stream(path, parent string, s Stream) {
+	if parent == "Stop" {
+		v.required(path, "stream name", s.Name)
+		return
+	}
+	v.required(path, "url", s.Url)
+	if s.Url != "" && !strings.HasPrefix(s.Url, "wss://") {
+		v.errorf(path, "url must start with 'wss://'")
+	}
+	if parent == "Connect" {
+		v.enum(path, "track", s.Track, TwiInboundTrack)
+	} else {
+		v.enum(path, "track", s.Track, TwiInboundTrack, TwiOutboundTrack,
+			TwiBothTracks)
+	}
+	v.method(path, "statusCallbackMethod", s.StatusCallbackMethod)
+	for _, p := range s.Parameters {
+		v.required(path, "parameter name", p.Name)
+	}
+}
+
+// callbacks validates the status callback attributes of a Dial noun
+func (v *validator) callbacks(path, method, events string) {
+	v.method(path, "statusCallbackMethod", method)
+	v.events(path, "statusCallbackEvent", events, callEvents...)
+}
+
+// alone reports nouns that can't be combined with other nouns in a Dial
+func (v *validator) alone(path string, nested []interface{}) {
+	if len(nested) > 1 {
+		v.errorf(path, "can't be dialed together with other nouns")
+	}
+}
+
+// callSid matches the sid of a call
+var callSid = regexp.MustCompile(`^CA[0-9a-fA-F]{32}$`)
+
+func (v *validator) conference(path string, c Conference) {
+	v.required(path, "conference name", c.Name)
+	v.method(path, "waitMethod", c.WaitMethod)
+	v.enum(path, "beep", c.Beep, "true", "false", "onEnter", "onExit")
+	v.match(path, "coach", c.Coach, callSid)
+	v.method(path, "statusCallbackMethod", c.StatusCallbackMethod)
+	for _, e := range c.StatusCallbackEvent {
+		v.enum(path, "statusCallbackEvent", e, TwiConferenceStart,
+			TwiConferenceEnd, TwiConferenceJoin, TwiConferenceLeave,
+			TwiConferenceMute, TwiConferenceHold, TwiConferenceModify,
+			TwiConferenceSpeaker, TwiConferenceAnnouncement)
+	}
+	if len(c.StatusCallbackEvent) > 0 && c.StatusCallback == "" {
+		v.errorf(path, "statusCallbackEvent requires statusCallback")
+	}
+	v.enum(path, "record", c.Record, "do-not-record", "record-from-start")
+	v.method(path, "recordingStatusCallbackMethod", c.RecordingStatusCallbackMethod)
+	v.events(path, "recordingStatusCallbackEvent", c.RecordingStatusCallbackEvent,
+		"in-progress", "completed", "absent")
+	v.enum(path, "trim", c.Trim, "trim-silence", "do-not-trim")
+	v.enum(path, "region", c.Region, "us1", "us2", "ie1", "de1", "sg1",
+		"br1", "au1", "jp1")
+	v.enum(path, "jitterBufferSize", c.JitterBufferSize, "off", "small",
+		"medium", "large")
+	if c.MaxParticipants != 0 {
+		v.between(path, "maxParticipants", c.MaxParticipants, 2, 250)
+	}
+}
+
+func (v *validator) gather(path string, g Gather) {
+	v.method(path, "method", g.Method)
+	v.optMin(path, "timeout", g.Timeout, 1)
+	v.min(path, "numDigits", g.NumDigits, 0)
+	v.finishOnKey(path, g.FinishOnKey)
+	v.enum(path, "input", g.Input, TwiDTMF, TwiSpeech, TwiDTMF+" "+TwiSpeech,
+		TwiSpeech+" "+TwiDTMF)
+	if g.SpeechTimeout != TwiAuto {
+		v.match(path, "speechTimeout", g.SpeechTimeout, seconds)
+	}
+	v.enum(path, "speechModel", g.SpeechModel, TwiDefaultModel,
+		TwiNumbersAndCommands, TwiPhoneCall, TwiExperimentalConversations,
+		TwiExperimentalUtterances)
+	if isTrue(g.Enhanced) && g.SpeechModel != TwiPhoneCall {
+		v.errorf(path, "enhanced requires speechModel %s", TwiPhoneCall)
+	}
+	if g.SpeechModel == TwiExperimentalConversations && g.SpeechTimeout == TwiAuto {
+		v.errorf(path, "speechTimeout auto is not supported by %s", g.SpeechModel)
+	}
+	v.method(path, "partialResultCallbackMethod", g.PartialResultCallbackMethod)
+	for _, c := range v.children(path, g.Nested) {
+		switch n := c.elem.(type) {
+		default:
+			v.errorf(c.path, "not allowed in Gather")
+		case Pause:
+			v.min(c.path, "length", n.Length, 0)
+		case Play:
+			v.play(c.path, n)
+		case Say:
+			v.say(c.path, n)
+		}
+	}
+}
+
+func (v *validator) message(path string, m Message) {
+	v.method(path, "method", m.Method)
+	if m.Body == "" && m.Media == "" {
+		v.errorf(path, "body or media required")
+	}
+}
+
+// workflowSid matches the sid of a TaskRouter workflow
+var workflowSid = regexp.MustCompile(`^WW[0-9a-fA-F]{32}$`)
+
+func (v *validator) enqueue(path string, e Enqueue) {
+	v.method(path, "method", e.Method)
+	v.method(path, "waitUrlMethod", e.WaitUrlMethod)
+	v.match(path, "workflowSid", e.WorkflowSid, workflowSid)
+	if e.WorkflowSid == "" {
+		v.required(path, "queue name", e.Name)
+		if e.Task != nil {
+			v.errorf(path, "task requires workflowSid")
+		}
+		return
+	}
+	if e.Name != "" {
+		v.errorf(path, "queue name and workflowSid are exclusive")
+	}
+	if e.Task == nil {
+		return
+	}
+	t := e.Task
+	v.min(path, "task priority", t.Priority, 0)
+	v.min(path, "task timeout", t.Timeout, 0)
+	var attrs map[string]interface{}
+	if err := json.Unmarshal([]byte(t.Attributes), &attrs); err != nil {
+		v.errorf(path, "task attributes must be a JSON object: %v", err)
+	}
+}
+
+// cardTypes are the validCardTypes and Prompt cardType values of Pay
+var cardTypes = []string{"visa", "mastercard", "amex", "maestro", "discover",
+	"optima", "jcb", "diners-club", "enroute"}
+
+// payErrors are the Prompt errorType values of Pay
+var payErrors = []string{"timeout", "invalid-card-number", "invalid-card-type",
+	"invalid-date", "invalid-security-code", "invalid-postal-code",
+	"invalid-bank-routing-number", "invalid-bank-account-number",
+	"input-matching-failed"}
+
+// amount matches a decimal charge amount
+var amount = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// currency matches a lowercase ISO 4217 currency code
+var currency = regexp.MustCompile(`^[a-z]{3}$`)
+
+func (v *validator) pay(path string, p Pay) {
+	v.enum(path, "input", p.Input, TwiDTMF)
+	v.method(path, "statusCallbackMethod", p.StatusCallbackMethod)
+	v.min(path, "timeout", p.Timeout, 0)
+	v.between(path, "maxAttempts", p.MaxAttempts, 1, 3)
+	v.min(path, "minPostalCodeLength", p.MinPostalCodeLength, 0)
+	v.enum(path, "paymentMethod", p.PaymentMethod, TwiCreditCard, TwiACHDebit)
+	v.enum(path, "bankAccountType", p.BankAccountType, "consumer-checking",
+		"consumer-savings", "commercial-checking")
+	v.enum(path, "tokenType", p.TokenType, TwiOneTime, TwiReusable,
+		TwiPaymentMethodToken)
+	v.match(path, "chargeAmount", p.ChargeAmount, amount)
+	v.match(path, "currency", p.Currency, currency)
+	v.events(path, "validCardTypes", p.ValidCardTypes, cardTypes...)
+	if p.BankAccountType != "" && p.PaymentMethod != TwiACHDebit {
+		v.errorf(path, "bankAccountType requires paymentMethod %s", TwiACHDebit)
+	}
+	charge := strings.Trim(p.ChargeAmount, "0.") != ""
+	if charge && p.TokenType != "" && p.TokenType != TwiOneTime {
+		v.errorf(path, "tokenType %s can't be used with a chargeAmount", p.TokenType)
+	}
+	for _, c := range v.children(path, p.Nested) {
+		switch n := c.elem.(type) {
+		default:
+			v.errorf(c.path, "not allowed in Pay")
+		case Prompt:
+			v.prompt(c.path, n)
+		}
+	}
+	for _, p := range p.Parameters {
+		v.required(path, "parameter name", p.Name)
+	}
+}
+
+func (v *validator) prompt(path string, p Prompt) {
+	v.required(path, "for", p.For)
+	v.enum(path, "for", p.For, TwiPayCardNumber, TwiPayExpirationDate,
+		TwiPaySecurityCode, TwiPayPostalCode, TwiPayBankRoutingNumber,
+		TwiPayBankAccountNumber, TwiPayProcessing)
+	for _, a := range strings.Fields(p.Attempt) {
+		v.match(path, "attempt", a, seconds)
+	}
+	v.events(path, "cardType", p.CardType, cardTypes...)
+	v.events(path, "errorType", p.ErrorType, payErrors...)
+	for _, c := range v.children(path, p.Nested) {
+		switch n := c.elem.(type) {
+		default:
+			v.errorf(c.path, "not allowed in Prompt")
+		case Pause:
+			v.min(c.path, "length", n.Length, 0)
+		case Play:
+			v.play(c.path, n)
+		case Say:
+			v.say(c.path, n)
+		}
+	}
+}
+
+func (v *validator) play(path string, p Play) {
+	v.optMin(path, "loop", p.Loop, 0)
+	if p.Url == "" && p.Digits == "" {
+		v.errorf(path, "url or digits required")
+	}
+}
+
+func (v *validator) say(path string, s Say) {
+	v.optMin(path, "loop", s.Loop, 0)
+	name := s.Voice
+	if name == "" {
+		name = TwiMan
+	}
+	voice, ok := LookupVoice(name)
+	if !ok {
+		v.errorf(path, "unknown voice '%s'", s.Voice)
+		return
+	}
+	if s.Language != "" && !voice.Speaks(s.Language) {
+		v.errorf(path, "voice '%s' does not speak language '%s'", name, s.Language)
+	}
+	if len(s.Nested) > 0 {
+		if !voice.SSML {
+			v.errorf(path, "voice '%s' does not speak SSML", name)
+		}
+		v.ssml(path, s.Nested, false, false)
+	}
+}
+
+// seconds matches a whole number, of seconds or attempts
+var seconds = regexp.MustCompile(`^[0-9]+$`)
+
+// ssmlDuration matches the time of a break, e.g. "500ms" or "2.5s"
+var ssmlDuration = regexp.MustCompile(`^([0-9]+ms|[0-9]+(\.[0-9]+)?s)$`)
+
+// ssmlVolume, ssmlRate and ssmlPitch match relative prosody values
+var (
+	ssmlVolume = regexp.MustCompile(`^[+-][0-9]+(\.[0-9]+)?dB$`)
+	ssmlRate   = regexp.MustCompile(`^[0-9]+%$`)
+	ssmlPitch  = regexp.MustCompile(`^[+-][0-9]+(\.[0-9]+)?%$`)
+)
+
+// ssml validates the SSML nested in a Say or SSML element, inP and inS
+// telling whether it is inside a paragraph or sentence
+func (v *validator) ssml(path string, nested []interface{}, inP, inS bool) {
+	for _, c := range v.children(path, nested) {
+		switch n := c.elem.(type) {
+		default:
+			v.errorf(c.path, "not allowed in Say")
+		case Element:
+			v.errorf(c.path, "unknown SSML element")
+		case SSMLText:
+		case SSMLAmazonEffect:
+			v.enum(c.path, "name", n.Name, "whispered", "drc")
+			v.required(c.path, "name", n.Name)
+			v.ssml(c.path, n.Nested, inP, inS)
+		case SSMLBreak:
+			v.enum(c.path, "strength", n.Strength, "none", "x-weak", "weak",
+				"medium", "strong", "x-strong")
+			v.match(c.path, "time", n.Time, ssmlDuration)
+		case SSMLEmphasis:
+			v.enum(c.path, "level", n.Level, "strong", "moderate", "reduced")
+			v.ssml(c.path, n.Nested, inP, inS)
+		case SSMLLang:
+			v.required(c.path, "xml:lang", n.Lang)
+			v.ssml(c.path, n.Nested, inP, inS)
+		case SSMLP:
+			if inP || inS {
+				v.errorf(c.path, "paragraph can't be nested in a paragraph or sentence")
+			}
+			v.ssml(c.path, n.Nested, true, inS)
+		case SSMLPhoneme:
+			v.enum(c.path, "alphabet", n.Alphabet, "ipa", "x-sampa")
+			v.required(c.path, "ph", n.Ph)
+		case SSMLProsody:
+			v.keywordOr(c.path, "volume", n.Volume, ssmlVolume, "default",
+				"silent", "x-soft", "soft", "medium", "loud", "x-loud")
+			v.keywordOr(c.path, "rate", n.Rate, ssmlRate, "x-slow", "slow",
+				"medium", "fast", "x-fast")
+			v.keywordOr(c.path, "pitch", n.Pitch, ssmlPitch, "default",
+				"x-low", "low", "medium", "high", "x-high")
+			v.ssml(c.path, n.Nested, inP, inS)
+		case SSMLS:
+			if inS {
+				v.errorf(c.path, "sentence can't be nested in a sentence")
+			}
+			v.ssml(c.path, n.Nested, inP, true)
+		case SSMLSayAs:
+			v.required(c.path, "interpret-as", n.InterpretAs)
+			v.enum(c.path, "interpret-as", n.InterpretAs, "character",
+				"spell-out", "cardinal", "number", "ordinal", "digits",
+				"fraction", "unit", "date", "time", "address", "expletive",
+				"telephone")
+			if n.Format != "" && n.InterpretAs != "date" {
+				v.errorf(c.path, "format is only allowed with interpret-as date")
+			}
+			v.enum(c.path, "format", n.Format, "mdy", "dmy", "ymd", "md",
+				"dm", "ym", "my", "d", "m", "y", "yyyymmdd")
+		case SSMLSub:
+			v.required(c.path, "alias", n.Alias)
+		case SSMLW:
+			v.enum(c.path, "role", n.Role, "amazon:VB", "amazon:VBD",
+				"amazon:NN", "amazon:SENSE_1")
+			v.required(c.path, "role", n.Role)
+		}
+	}
+}
+
+// match checks an optional attribute against a pattern
+func (v *validator) match(path, attr, value string, pattern *regexp.Regexp) {
+	if value != "" && !pattern.MatchString(value) {
+		v.errorf(path, "%s has invalid value '%s'", attr, value)
+	}
+}
+
+// keywordOr checks that an optional attribute is one of the keywords or
+// matches pattern
+func (v *validator) keywordOr(path, attr, value string, pattern *regexp.Regexp,
+	keywords ...string) {
+
+	for _, k := range keywords {
+		if value == k {
+			return
+		}
+	}
+	v.match(path, attr, value, pattern)
+}
+
+func (v *validator) method(path, attr, method string) {
+	v.enum(path, attr, method, "GET", "POST")
+}
+
+func (v *validator) required(path, what, value string) {
+	if value == "" {
+		v.errorf(path, "%s required", what)
+	}
+}
+
+// enum checks that an optional attribute has one of the allowed values
+func (v *validator) enum(path, attr, value string, allowed ...string) {
+	if value == "" {
+		return
+	}
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.errorf(path, "%s must be one of %s, not '%s'", attr,
+		strings.Join(allowed, ", "), value)
+}
+
+// events checks that each of the space separated values of an optional
+// attribute is allowed
+func (v *validator) events(path, attr, value string, allowed ...string) {
+	for _, e := range strings.Fields(value) {
+		v.enum(path, attr, e, allowed...)
+	}
+}
+
+func (v *validator) min(path, attr string, value, min int) {
+	if value < min {
+		v.errorf(path, "%s must be at least %d, not %d", attr, min, value)
+	}
+}
+
+// optMin checks the minimum of an optional int attribute
+func (v *validator) optMin(path, attr string, value *int, min int) {
+	if value != nil {
+		v.min(path, attr, *value, min)
+	}
+}
+
+// isTrue tells whether an optional bool attribute is set to true
+func isTrue(b *bool) bool {
+	return b != nil && *b
+}
+
+// between checks the range of an optional attribute, zero being unset
+func (v *validator) between(path, attr string, value, min, max int) {
+	if value != 0 && (value < min || value > max) {
+		v.errorf(path, "%s must be between %d and %d, not %d", attr, min,
+			max, value)
+	}
+}
+
+func (v *validator) finishOnKey(path, keys string) {
+	for _, k := range keys {
+		if !strings.ContainsRune("0123456789#*", k) {
+			v.errorf(path, "finishOnKey must be digits, '#' or '*', not '%s'", keys)
+			return
+		}
+	}
+}
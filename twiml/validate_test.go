@@ -0,0 +1,63 @@
+package twiml_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/tmc/twilio/twiml"
+)
+
+// validationPaths returns the paths of the ValidationErrors err holds
+func validationPaths(err error) []string {
+	errs, _ := err.(twiml.ValidationErrors)
+	var paths []string
+	for _, e := range errs {
+		paths = append(paths, e.Path)
+	}
+	return paths
+}
+
+func TestValidate(t *testing.T) {
+	resp := twiml.NewResponse()
+	resp.Gather(twiml.Gather{Timeout: twiml.Int(0), FinishOnKey: "x", SpeechTimeout: "soon"},
+		twiml.Say{Text: "Hi", Voice: twiml.TwiMan, Language: twiml.TwiJapaneseJapan})
+	resp.Dial(twiml.Dial{Number: "+15005550001"}, twiml.Number{Number: "+15005550002"})
+	resp.Action(twiml.Reject{Reason: "nope"}, twiml.Say{Text: "unreachable"},
+		twiml.Hangup{}, twiml.Pause{Length: 1})
+
+	err := resp.Validate()
+	want := []string{
+		"Response/Gather[1]",
+		"Response/Gather[1]",
+		"Response/Gather[1]",
+		"Response/Gather[1]/Say[1]",
+		"Response/Dial[1]",
+		"Response/Reject[1]",
+		"Response/Say[1]",
+		"Response/Hangup[1]",
+		"Response/Pause[1]",
+	}
+	if paths := validationPaths(err); !reflect.DeepEqual(paths, want) {
+		t.Errorf("got errors %v, want paths %v", err, want)
+	}
+
+	var b bytes.Buffer
+	resp.ValidateOnSend = true
+	if err := resp.Send(&b); err == nil || b.Len() != 0 {
+		t.Errorf("Send wrote invalid response: %v %q", err, b.String())
+	}
+}
+
+func TestValidateValid(t *testing.T) {
+	resp := twiml.NewResponse()
+	resp.Gather(twiml.Gather{NumDigits: 1, Action: "/menu", Method: "POST"},
+		twiml.Say{Text: "Hi", Voice: twiml.TwiAlice, Language: twiml.TwiEnglishUSA})
+	resp.Gather(twiml.Gather{Input: "dtmf speech", SpeechTimeout: twiml.TwiAuto,
+		SpeechModel: twiml.TwiPhoneCall, Enhanced: twiml.Bool(true), Hints: "sales, support"})
+	resp.Dial(twiml.Dial{}, twiml.Conference{Name: "room", Beep: "onEnter"})
+	resp.Action(twiml.Hangup{})
+	if err := resp.Validate(); err != nil {
+		t.Error(err)
+	}
+}
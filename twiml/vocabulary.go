@@ -1,51 +1,132 @@
 package twiml
 
-import "encoding/xml"
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+)
+
+// Bool attributes, and int attributes for which 0 is meaningful such as
+// Play.Loop, are pointers so that false and 0 can be written to override
+// twilio defaults like playBeep="true". They are omitted when nil.
+
+// Bool returns a pointer to b, for setting optional bool attributes.
+func Bool(b bool) *bool {
+	return &b
+}
+
+// Int returns a pointer to n, for setting optional int attributes.
+func Int(n int) *int {
+	return &n
+}
 
 type Client struct {
-	XMLName xml.Name `xml:"Client"`
-	Method  string   `xml:"method,attr,omitempty"`
-	Url     string   `xml:"URL,omitempty"`
-	Name    string   `xml:",chardata"`
+	XMLName              xml.Name `xml:"Client"`
+	Method               string   `xml:"method,attr,omitempty"`
+	Url                  string   `xml:"url,attr,omitempty"`
+	StatusCallback       string   `xml:"statusCallback,attr,omitempty"`
+	StatusCallbackEvent  string   `xml:"statusCallbackEvent,attr,omitempty"`
+	StatusCallbackMethod string   `xml:"statusCallbackMethod,attr,omitempty"`
+	Name                 string   `xml:",chardata"`
+	// Identity replaces Name when the client is passed Parameters.
+	Identity   string      `xml:"Identity,omitempty"`
+	Parameters []Parameter `xml:"Parameter"`
 }
 
+// Conference joins the call to the named conference room. A Coach joins as
+// a supervisor heard only by the participant whose call it names.
 type Conference struct {
-	XMLName                xml.Name `xml:"Conference"`
-	Muted                  bool     `xml:"muted,attr,omitempty"`
-	Beep                   string   `xml:"beep,attr,omitempty"`
-	StartConferenceOnEnter bool     `xml:"startConferenceOnEnter,attr,omitempty"`
-	EndConferenceOnExit    bool     `xml:"endConferenceOnExit,attr,omitempty"`
-	WaitUrl                string   `xml:"waitUrl,attr,omitempty"`
-	WaitMethod             string   `xml:"waitMethod,attr,omitempty"`
-	MaxParticipants        int      `xml:"maxParticipants,attr,omitempty"`
-	EventCallbackURL       string   `xml:"eventCallbackUrl,attr,omitempty"`
-	StatusCallback         string   `xml:"statusCallback,attr,omitempty"`
-	StatusCallbackEvent    string   `xml:"statusCallbackEvent,attr,omitempty"`
-	Record                 string   `xml:"record,attr,omitempty"`
-	Trim                   string   `xml:"trim,attr,omitempty"`
-	Name                   string   `xml:",chardata"`
+	XMLName                       xml.Name         `xml:"Conference"`
+	Muted                         *bool            `xml:"muted,attr,omitempty"`
+	Beep                          string           `xml:"beep,attr,omitempty"`
+	StartConferenceOnEnter        *bool            `xml:"startConferenceOnEnter,attr,omitempty"`
+	EndConferenceOnExit           *bool            `xml:"endConferenceOnExit,attr,omitempty"`
+	ParticipantLabel              string           `xml:"participantLabel,attr,omitempty"`
+	Coach                         string           `xml:"coach,attr,omitempty"`
+	WaitUrl                       string           `xml:"waitUrl,attr,omitempty"`
+	WaitMethod                    string           `xml:"waitMethod,attr,omitempty"`
+	MaxParticipants               int              `xml:"maxParticipants,attr,omitempty"`
+	EventCallbackURL              string           `xml:"eventCallbackUrl,attr,omitempty"`
+	StatusCallback                string           `xml:"statusCallback,attr,omitempty"`
+	StatusCallbackMethod          string           `xml:"statusCallbackMethod,attr,omitempty"`
+	StatusCallbackEvent           ConferenceEvents `xml:"statusCallbackEvent,attr,omitempty"`
+	Record                        string           `xml:"record,attr,omitempty"`
+	RecordingStatusCallback       string           `xml:"recordingStatusCallback,attr,omitempty"`
+	RecordingStatusCallbackMethod string           `xml:"recordingStatusCallbackMethod,attr,omitempty"`
+	RecordingStatusCallbackEvent  string           `xml:"recordingStatusCallbackEvent,attr,omitempty"`
+	Trim                          string           `xml:"trim,attr,omitempty"`
+	Region                        string           `xml:"region,attr,omitempty"`
+	JitterBufferSize              string           `xml:"jitterBufferSize,attr,omitempty"`
+	Name                          string           `xml:",chardata"`
+}
+
+// ConferenceEvents lists the conference events sent to the StatusCallback
+// of a Conference, e.g. TwiConferenceStart. It is written as a space
+// separated attribute.
+type ConferenceEvents []string
+
+// MarshalXMLAttr writes the events separated by spaces.
+func (e ConferenceEvents) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	return xml.Attr{Name: name, Value: strings.Join(e, " ")}, nil
+}
+
+// UnmarshalXMLAttr splits the events at spaces.
+func (e *ConferenceEvents) UnmarshalXMLAttr(attr xml.Attr) error {
+	*e = strings.Fields(attr.Value)
+	return nil
+}
+
+// UnmarshalJSON accepts a list as well as a space separated string.
+func (e *ConferenceEvents) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*e = strings.Fields(s)
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(e))
+}
+
+type Connect struct {
+	XMLName xml.Name `xml:"Connect"`
+	Action  string   `xml:"action,attr,omitempty"`
+	Method  string   `xml:"method,attr,omitempty"`
+	Nested  []interface{}
 }
 
 type Dial struct {
-	XMLName      xml.Name `xml:"Dial"`
-	Action       string   `xml:"action,attr,omitempty"`
-	Method       string   `xml:"method,attr,omitempty"`
-	Timeout      int      `xml:"timeout,attr,omitempty"`
-	HangupOnStar bool     `xml:"hangupOnStar,attr,omitempty"`
-	TimeLimit    int      `xml:"timeLimit,attr,omitempty"`
-	CallerId     string   `xml:"callerId,attr,omitempty"`
-	Record       bool     `xml:"record,attr,omitempty"`
-	Number       string   `xml:",chardata"`
-	Nested       []interface{}
+	XMLName                       xml.Name `xml:"Dial"`
+	Action                        string   `xml:"action,attr,omitempty"`
+	Method                        string   `xml:"method,attr,omitempty"`
+	Timeout                       int      `xml:"timeout,attr,omitempty"`
+	HangupOnStar                  *bool    `xml:"hangupOnStar,attr,omitempty"`
+	TimeLimit                     int      `xml:"timeLimit,attr,omitempty"`
+	CallerId                      string   `xml:"callerId,attr,omitempty"`
+	Record                        string   `xml:"record,attr,omitempty"`
+	AnswerOnBridge                *bool    `xml:"answerOnBridge,attr,omitempty"`
+	RingTone                      string   `xml:"ringTone,attr,omitempty"`
+	RecordingStatusCallback       string   `xml:"recordingStatusCallback,attr,omitempty"`
+	RecordingStatusCallbackMethod string   `xml:"recordingStatusCallbackMethod,attr,omitempty"`
+	RecordingStatusCallbackEvent  string   `xml:"recordingStatusCallbackEvent,attr,omitempty"`
+	RecordingTrack                string   `xml:"recordingTrack,attr,omitempty"`
+	Trim                          string   `xml:"trim,attr,omitempty"`
+	ReferUrl                      string   `xml:"referUrl,attr,omitempty"`
+	ReferMethod                   string   `xml:"referMethod,attr,omitempty"`
+	Sequential                    *bool    `xml:"sequential,attr,omitempty"`
+	Number                        string   `xml:",chardata"`
+	Nested                        []interface{}
 }
 
+// Enqueue places the call in the named queue or, with WorkflowSid and Task,
+// creates a TaskRouter task routed by the workflow.
 type Enqueue struct {
 	XMLName       xml.Name `xml:"Enqueue"`
 	Action        string   `xml:"action,attr,omitempty"`
 	Method        string   `xml:"method,attr,omitempty"`
 	WaitUrl       string   `xml:"waitUrl,attr,omitempty"`
 	WaitUrlMethod string   `xml:"waitUrlMethod,attr,omitempty"`
+	WorkflowSid   string   `xml:"workflowSid,attr,omitempty"`
 	Name          string   `xml:",chardata"`
+	Task          *Task    `xml:"Task,omitempty"`
 }
 
 type Hangup struct {
@@ -68,11 +149,15 @@ type Message struct {
 }
 
 type Number struct {
-	XMLName    xml.Name `xml:"Number"`
-	SendDigits string   `xml:"sendDigits,attr,omitempty"`
-	Url        string   `xml:"url,attr,omitempty"`
-	Method     string   `xml:"method,attr,omitempty"`
-	Number     string   `xml:",chardata"`
+	XMLName              xml.Name `xml:"Number"`
+	SendDigits           string   `xml:"sendDigits,attr,omitempty"`
+	Url                  string   `xml:"url,attr,omitempty"`
+	Method               string   `xml:"method,attr,omitempty"`
+	StatusCallback       string   `xml:"statusCallback,attr,omitempty"`
+	StatusCallbackEvent  string   `xml:"statusCallbackEvent,attr,omitempty"`
+	StatusCallbackMethod string   `xml:"statusCallbackMethod,attr,omitempty"`
+	Byoc                 string   `xml:"byoc,attr,omitempty"`
+	Number               string   `xml:",chardata"`
 }
 
 type Pause struct {
@@ -80,13 +165,61 @@ type Pause struct {
 	Length  int      `xml:"length,attr,omitempty"`
 }
 
+// Parameter is a custom parameter nested in Stream or Client, passed on to
+// the receiver of the stream or call, or nested in Pay, passed on to the
+// payment connector.
+type Parameter struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// Pay captures a card or bank account payment from the caller's key
+// presses, handled by the payment connector so that the digits never reach
+// the application. Nested holds Prompt, Parameters are passed on to the
+// payment connector.
+type Pay struct {
+	XMLName              xml.Name `xml:"Pay"`
+	Input                string   `xml:"input,attr,omitempty"`
+	Action               string   `xml:"action,attr,omitempty"`
+	StatusCallback       string   `xml:"statusCallback,attr,omitempty"`
+	StatusCallbackMethod string   `xml:"statusCallbackMethod,attr,omitempty"`
+	Timeout              int      `xml:"timeout,attr,omitempty"`
+	MaxAttempts          int      `xml:"maxAttempts,attr,omitempty"`
+	SecurityCode         *bool    `xml:"securityCode,attr,omitempty"`
+	PostalCode           string   `xml:"postalCode,attr,omitempty"`
+	MinPostalCodeLength  int      `xml:"minPostalCodeLength,attr,omitempty"`
+	PaymentConnector     string   `xml:"paymentConnector,attr,omitempty"`
+	PaymentMethod        string   `xml:"paymentMethod,attr,omitempty"`
+	BankAccountType      string   `xml:"bankAccountType,attr,omitempty"`
+	TokenType            string   `xml:"tokenType,attr,omitempty"`
+	ChargeAmount         string   `xml:"chargeAmount,attr,omitempty"`
+	Currency             string   `xml:"currency,attr,omitempty"`
+	Description          string   `xml:"description,attr,omitempty"`
+	ValidCardTypes       string   `xml:"validCardTypes,attr,omitempty"`
+	Language             string   `xml:"language,attr,omitempty"`
+	Nested               []interface{}
+	Parameters           []Parameter `xml:"Parameter"`
+}
+
 type Play struct {
 	XMLName xml.Name `xml:"Play"`
-	Loop    int      `xml:"loop,attr,omitempty"`
-	Digits  int      `xml:"digits,attr,omitempty"`
+	Loop    *int     `xml:"loop,attr,omitempty"`
+	Digits  string   `xml:"digits,attr,omitempty"`
 	Url     string   `xml:",chardata"`
 }
 
+// Prompt replaces the default prompt of Pay for an input, optionally only
+// for given attempts, card types or errors. Nested holds Say, Play and Pause.
+type Prompt struct {
+	XMLName               xml.Name `xml:"Prompt"`
+	For                   string   `xml:"for,attr,omitempty"`
+	Attempt               string   `xml:"attempt,attr,omitempty"`
+	CardType              string   `xml:"cardType,attr,omitempty"`
+	ErrorType             string   `xml:"errorType,attr,omitempty"`
+	RequireMatchingInputs *bool    `xml:"requireMatchingInputs,attr,omitempty"`
+	Nested                []interface{}
+}
+
 type Queue struct {
 	XMLName xml.Name `xml:"Queue"`
 	Url     string   `xml:"url,attr,omitempty"`
@@ -98,12 +231,12 @@ type Record struct {
 	XMLName            xml.Name `xml:"Record"`
 	Action             string   `xml:"action,attr,omitempty"`
 	Method             string   `xml:"method,attr,omitempty"`
-	Timeout            int      `xml:"timeout,attr,omitempty"`
+	Timeout            *int     `xml:"timeout,attr,omitempty"`
 	FinishOnKey        string   `xml:"finishOnKey,attr,omitempty"`
 	MaxLength          int      `xml:"maxLength,attr,omitempty"`
-	Transcribe         bool     `xml:"transcribe,attr,omitempty"`
+	Transcribe         *bool    `xml:"transcribe,attr,omitempty"`
 	TranscribeCallback string   `xml:"transcribeCallback,attr,omitempty"`
-	PlayBeep           bool     `xml:"playBeep,attr,omitempty"`
+	PlayBeep           *bool    `xml:"playBeep,attr,omitempty"`
 }
 
 type Redirect struct {
@@ -120,31 +253,93 @@ type Reject struct {
 type Response struct {
 	XMLName  xml.Name `xml:"Response"`
 	Response []interface{}
+	// ValidateOnSend makes Send fail with the Validate errors of an
+	// invalid response instead of writing it.
+	ValidateOnSend bool `xml:"-"`
 }
 
 type Say struct {
 	XMLName  xml.Name `xml:"Say"`
 	Voice    string   `xml:"voice,attr,omitempty"`
 	Language string   `xml:"language,attr,omitempty"`
-	Loop     int      `xml:"loop,attr,omitempty"`
+	Loop     *int     `xml:"loop,attr,omitempty"`
 	Text     string   `xml:",chardata"`
+	// Nested holds SSML elements and SSMLText, spoken after Text.
+	Nested []interface{}
+}
+
+type Start struct {
+	XMLName xml.Name `xml:"Start"`
+	Action  string   `xml:"action,attr,omitempty"`
+	Method  string   `xml:"method,attr,omitempty"`
+	Nested  []interface{}
+}
+
+type Stop struct {
+	XMLName xml.Name `xml:"Stop"`
+	Nested  []interface{}
+}
+
+type Stream struct {
+	XMLName              xml.Name    `xml:"Stream"`
+	Name                 string      `xml:"name,attr,omitempty"`
+	Url                  string      `xml:"url,attr,omitempty"`
+	Track                string      `xml:"track,attr,omitempty"`
+	StatusCallback       string      `xml:"statusCallback,attr,omitempty"`
+	StatusCallbackMethod string      `xml:"statusCallbackMethod,attr,omitempty"`
+	Parameters           []Parameter `xml:"Parameter"`
+}
+
+// Task is the TaskRouter task of an Enqueue, with its attributes as a JSON
+// object.
+type Task struct {
+	Priority   int    `xml:"priority,attr,omitempty"`
+	Timeout    int    `xml:"timeout,attr,omitempty"`
+	Attributes string `xml:",chardata"`
+}
+
+// NewTask creates a task with attributes encoded to JSON.
+func NewTask(attributes interface{}) (*Task, error) {
+	b, err := json.Marshal(attributes)
+	if err != nil {
+		return nil, err
+	}
+	return &Task{Attributes: string(b)}, nil
+}
+
+// Decode decodes the JSON attributes of the task into v.
+func (t Task) Decode(v interface{}) error {
+	return json.Unmarshal([]byte(t.Attributes), v)
 }
 
 type Sip struct {
-	XMLName  xml.Name `xml:"Sip"`
-	Username string   `xml:"username,attr,omitempty"`
-	Password string   `xml:"password,attr,omitempty"`
-	Url      string   `xml:"url,attr,omitempty"`
-	Method   string   `xml:"method,attr,omitempty"`
-	Address  string   `xml:",chardata"`
+	XMLName              xml.Name `xml:"Sip"`
+	Username             string   `xml:"username,attr,omitempty"`
+	Password             string   `xml:"password,attr,omitempty"`
+	Url                  string   `xml:"url,attr,omitempty"`
+	Method               string   `xml:"method,attr,omitempty"`
+	StatusCallback       string   `xml:"statusCallback,attr,omitempty"`
+	StatusCallbackEvent  string   `xml:"statusCallbackEvent,attr,omitempty"`
+	StatusCallbackMethod string   `xml:"statusCallbackMethod,attr,omitempty"`
+	Address              string   `xml:",chardata"`
 }
 
 type Gather struct {
-	XMLName     xml.Name `xml:"Gather"`
-	Action      string   `xml:"action,attr,omitempty"`
-	Method      string   `xml:"method,attr,omitempty"`
-	Timeout     int      `xml:"timeout,attr,omitempty"`
-	FinishOnKey string   `xml:"finishOnKey,attr,omitempty"`
-	NumDigits   int      `xml:"numDigits,attr,omitempty"`
-	Nested      []interface{}
+	XMLName                     xml.Name `xml:"Gather"`
+	Action                      string   `xml:"action,attr,omitempty"`
+	Method                      string   `xml:"method,attr,omitempty"`
+	Timeout                     *int     `xml:"timeout,attr,omitempty"`
+	FinishOnKey                 string   `xml:"finishOnKey,attr,omitempty"`
+	NumDigits                   int      `xml:"numDigits,attr,omitempty"`
+	Input                       string   `xml:"input,attr,omitempty"`
+	Hints                       string   `xml:"hints,attr,omitempty"`
+	Language                    string   `xml:"language,attr,omitempty"`
+	SpeechTimeout               string   `xml:"speechTimeout,attr,omitempty"`
+	SpeechModel                 string   `xml:"speechModel,attr,omitempty"`
+	Enhanced                    *bool    `xml:"enhanced,attr,omitempty"`
+	ProfanityFilter             *bool    `xml:"profanityFilter,attr,omitempty"`
+	PartialResultCallback       string   `xml:"partialResultCallback,attr,omitempty"`
+	PartialResultCallbackMethod string   `xml:"partialResultCallbackMethod,attr,omitempty"`
+	ActionOnEmptyResult         *bool    `xml:"actionOnEmptyResult,attr,omitempty"`
+	Nested                      []interface{}
 }
@@ -0,0 +1,206 @@
+package twiml
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Voice describes a voice of the Say verb.
+type Voice struct {
+	// Name is the value of the voice attribute, e.g. "Polly.Joanna-Neural".
+	Name string
+	// Engine is TwiBasic, TwiStandard or TwiNeural for Polly, or TwiGoogle.
+	Engine string
+	// Gender is "female" or "male", empty for the basic man and woman.
+	Gender string
+	// Languages lists the language attribute values the voice speaks.
+	Languages []string
+	// SSML tells whether the voice speaks SSML nested in Say.
+	SSML bool
+}
+
+// Speaks reports whether the voice speaks language.
+func (v Voice) Speaks(language string) bool {
+	for _, l := range v.Languages {
+		if strings.EqualFold(l, language) {
+			return true
+		}
+	}
+	return false
+}
+
+// Voices is the catalog of basic and Polly voices. Google voices are
+// recognized by LookupVoice from their names instead.
+var Voices []Voice
+
+// pollyVoices lists the Polly voices by language. Standard voices have a
+// standard variant, neural ones a "-Neural" variant.
+var pollyVoices = []struct {
+	language         string
+	gender           string
+	name             string
+	standard, neural bool
+}{
+	{"arb", "female", "Zeina", true, false},
+	{"ar-AE", "female", "Hala", false, true},
+	{"ar-AE", "male", "Zayd", false, true},
+	{"ca-ES", "female", "Arlet", false, true},
+	{"cmn-CN", "female", "Zhiyu", true, true},
+	{"cy-GB", "female", "Gwyneth", true, false},
+	{"da-DK", "female", "Naja", true, false},
+	{"da-DK", "male", "Mads", true, false},
+	{"da-DK", "female", "Sofie", false, true},
+	{"de-AT", "female", "Hannah", false, true},
+	{"de-DE", "female", "Marlene", true, false},
+	{"de-DE", "female", "Vicki", true, true},
+	{"de-DE", "male", "Hans", true, false},
+	{"de-DE", "male", "Daniel", false, true},
+	{"en-AU", "female", "Nicole", true, false},
+	{"en-AU", "female", "Olivia", false, true},
+	{"en-AU", "male", "Russell", true, false},
+	{"en-GB", "female", "Amy", true, true},
+	{"en-GB", "female", "Emma", true, true},
+	{"en-GB", "male", "Brian", true, true},
+	{"en-GB", "male", "Arthur", false, true},
+	{"en-GB-WLS", "male", "Geraint", true, false},
+	{"en-IE", "female", "Niamh", false, true},
+	{"en-IN", "female", "Aditi", true, false},
+	{"en-IN", "female", "Raveena", true, false},
+	{"en-IN", "female", "Kajal", false, true},
+	{"en-NZ", "female", "Aria", false, true},
+	{"en-US", "female", "Ivy", true, true},
+	{"en-US", "female", "Joanna", true, true},
+	{"en-US", "female", "Kendra", true, true},
+	{"en-US", "female", "Kimberly", true, true},
+	{"en-US", "female", "Salli", true, true},
+	{"en-US", "female", "Ruth", false, true},
+	{"en-US", "female", "Danielle", false, true},
+	{"en-US", "male", "Joey", true, true},
+	{"en-US", "male", "Justin", true, true},
+	{"en-US", "male", "Matthew", true, true},
+	{"en-US", "male", "Kevin", false, true},
+	{"en-US", "male", "Stephen", false, true},
+	{"en-US", "male", "Gregory", false, true},
+	{"en-ZA", "female", "Ayanda", false, true},
+	{"es-ES", "female", "Conchita", true, false},
+	{"es-ES", "female", "Lucia", true, true},
+	{"es-ES", "male", "Enrique", true, false},
+	{"es-ES", "male", "Sergio", false, true},
+	{"es-MX", "female", "Mia", true, true},
+	{"es-MX", "male", "Andres", false, true},
+	{"es-US", "female", "Lupe", true, true},
+	{"es-US", "female", "Penelope", true, false},
+	{"es-US", "male", "Miguel", true, false},
+	{"es-US", "male", "Pedro", false, true},
+	{"fi-FI", "female", "Suvi", false, true},
+	{"fr-CA", "female", "Chantal", true, false},
+	{"fr-CA", "female", "Gabrielle", false, true},
+	{"fr-CA", "male", "Liam", false, true},
+	{"fr-FR", "female", "Celine", true, false},
+	{"fr-FR", "female", "Lea", true, true},
+	{"fr-FR", "male", "Mathieu", true, false},
+	{"fr-FR", "male", "Remi", false, true},
+	{"hi-IN", "female", "Aditi", true, false},
+	{"hi-IN", "female", "Kajal", false, true},
+	{"is-IS", "female", "Dora", true, false},
+	{"is-IS", "male", "Karl", true, false},
+	{"it-IT", "female", "Carla", true, false},
+	{"it-IT", "female", "Bianca", true, true},
+	{"it-IT", "male", "Giorgio", true, false},
+	{"it-IT", "male", "Adriano", false, true},
+	{"ja-JP", "female", "Mizuki", true, false},
+	{"ja-JP", "female", "Kazuha", false, true},
+	{"ja-JP", "female", "Tomoko", false, true},
+	{"ja-JP", "male", "Takumi", true, true},
+	{"ko-KR", "female", "Seoyeon", true, true},
+	{"nb-NO", "female", "Liv", true, false},
+	{"nb-NO", "female", "Ida", false, true},
+	{"nl-BE", "female", "Lisa", false, true},
+	{"nl-NL", "female", "Lotte", true, false},
+	{"nl-NL", "female", "Laura", false, true},
+	{"nl-NL", "male", "Ruben", true, false},
+	{"pl-PL", "female", "Ewa", true, false},
+	{"pl-PL", "female", "Maja", true, false},
+	{"pl-PL", "female", "Ola", false, true},
+	{"pl-PL", "male", "Jacek", true, false},
+	{"pl-PL", "male", "Jan", true, false},
+	{"pt-BR", "female", "Camila", true, true},
+	{"pt-BR", "female", "Vitoria", true, true},
+	{"pt-BR", "male", "Ricardo", true, false},
+	{"pt-BR", "male", "Thiago", false, true},
+	{"pt-PT", "female", "Ines", true, true},
+	{"pt-PT", "male", "Cristiano", true, false},
+	{"ro-RO", "female", "Carmen", true, false},
+	{"ru-RU", "female", "Tatyana", true, false},
+	{"ru-RU", "male", "Maxim", true, false},
+	{"sv-SE", "female", "Astrid", true, false},
+	{"sv-SE", "female", "Elin", false, true},
+	{"tr-TR", "female", "Filiz", true, false},
+	{"yue-CN", "female", "Hiujin", false, true},
+}
+
+func init() {
+	basic := []string{TwiEnglish, TwiBritish, TwiSpanish, TwiFrench, TwiGerman}
+	Voices = []Voice{
+		{Name: TwiMan, Engine: TwiBasic, Languages: basic},
+		{Name: TwiWoman, Engine: TwiBasic, Languages: basic},
+		{Name: TwiAlice, Engine: TwiBasic, Gender: "female", Languages: []string{
+			TwiDanishDenmark, TwiGermanGermany, TwiEnglishAustralia,
+			TwiEnglishCanada, TwiEnglishUK, TwiEnglishIndia, TwiEnglishUSA,
+			TwiSpanishCatalan, TwiSpanishSpain, TwiSpanishMexico,
+			TwiFinishFinland, TwiFrenchCanada, TwiFrenchFrance,
+			TwiItalianItaly, TwiJapaneseJapan, TwiKoreanKorea,
+			TwiNorwegianNorway, TwiDutchNetherlands, TwiPolishPoland,
+			TwiPortugueseBrazil, TwiPortuguesePortugal, TwiRussianRussia,
+			TwiSwedishSweden, TwiChineseMandarin, TwiChineseCantonese,
+			TwiChineseTaiwanese}},
+	}
+	index := map[string]int{}
+	add := func(name, engine, gender, language string) {
+		if i, ok := index[name]; ok {
+			Voices[i].Languages = append(Voices[i].Languages, language)
+			return
+		}
+		index[name] = len(Voices)
+		Voices = append(Voices, Voice{Name: name, Engine: engine,
+			Gender: gender, Languages: []string{language}, SSML: true})
+	}
+	for _, p := range pollyVoices {
+		if p.standard {
+			add("Polly."+p.name, TwiStandard, p.gender, p.language)
+		}
+		if p.neural {
+			add("Polly."+p.name+"-Neural", TwiNeural, p.gender, p.language)
+		}
+	}
+}
+
+// googleVoice matches Google voice names, e.g. "Google.en-US-Wavenet-D"
+var googleVoice = regexp.MustCompile(
+	`^Google\.([a-z]{2,3}-[A-Z]{2})-(Standard|Wavenet|Neural2|News|Studio|Polyglot|Journey)-[A-Z]$`)
+
+// LookupVoice returns the voice with the given name from Voices, or a Google
+// voice speaking the language in its name.
+func LookupVoice(name string) (Voice, bool) {
+	for _, v := range Voices {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	if m := googleVoice.FindStringSubmatch(name); m != nil {
+		return Voice{Name: name, Engine: TwiGoogle, Languages: []string{m[1]},
+			SSML: true}, true
+	}
+	return Voice{}, false
+}
+
+// VoicesFor returns the voices of Voices that speak language.
+func VoicesFor(language string) []Voice {
+	var voices []Voice
+	for _, v := range Voices {
+		if v.Speaks(language) {
+			voices = append(voices, v)
+		}
+	}
+	return voices
+}
@@ -0,0 +1,50 @@
+package twiml_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tmc/twilio/twiml"
+)
+
+func TestValidateVoices(t *testing.T) {
+	for _, test := range []struct {
+		say   twiml.Say
+		valid bool
+	}{
+		{twiml.Say{Voice: twiml.TwiAlice, Language: twiml.TwiEnglishUK}, true},
+		{twiml.Say{Voice: twiml.TwiAlice, Language: "en-UK"}, false},
+		{twiml.Say{Voice: "Polly.Joanna-Neural", Language: twiml.TwiEnglishUSA}, true},
+		{twiml.Say{Voice: "Polly.Joanna", Language: twiml.TwiFrenchFrance}, false},
+		{twiml.Say{Voice: "Polly.Aditi", Language: "hi-IN"}, true},
+		{twiml.Say{Voice: "Polly.Arthur"}, false},
+		{twiml.Say{Voice: "Google.de-DE-Wavenet-B", Language: twiml.TwiGermanGermany}, true},
+		{twiml.Say{Voice: "Google.de-DE-Wavenet-B", Language: twiml.TwiEnglishUSA}, false},
+		{twiml.Say{Nested: []interface{}{twiml.SSMLBreak{}}}, false},
+		{twiml.Say{Language: twiml.TwiFrench}, true},
+		{twiml.Say{Language: twiml.TwiJapaneseJapan}, false},
+	} {
+		resp := twiml.NewResponse()
+		resp.Action(test.say)
+		if err := resp.Validate(); (err == nil) != test.valid {
+			t.Errorf("%s %s: got error %v", test.say.Voice, test.say.Language, err)
+		}
+	}
+}
+
+func ExampleVoicesFor() {
+	for _, v := range twiml.VoicesFor(twiml.TwiEnglishUK) {
+		fmt.Println(v.Name, v.Engine)
+	}
+	// output:
+	// man basic
+	// woman basic
+	// alice basic
+	// Polly.Amy standard
+	// Polly.Amy-Neural neural
+	// Polly.Emma standard
+	// Polly.Emma-Neural neural
+	// Polly.Brian standard
+	// Polly.Brian-Neural neural
+	// Polly.Arthur-Neural neural
+}
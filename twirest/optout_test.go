@@ -0,0 +1,85 @@
+package twirest
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/tmc/twilio/optout"
+)
+
+// fakeTransport answers every request with body and records the requests
+type fakeTransport struct {
+	body     string
+	requests []*http.Request
+}
+
+func (f *fakeTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	f.requests = append(f.requests, r)
+	return &http.Response{
+		StatusCode: http.StatusBadRequest,
+		Header:     http.Header{"Content-Type": {"application/xml"}},
+		Body:       io.NopCloser(strings.NewReader(f.body)),
+		Request:    r,
+	}, nil
+}
+
+const optedOutBody = `<TwilioResponse><RestException><Code>21610</Code>` +
+	`<Message>Attempt to send to unsubscribed recipient</Message>` +
+	`<Status>400</Status></RestException></TwilioResponse>`
+
+func fakeClient(body string) (*TwilioClient, *fakeTransport, *optout.MemoryStore) {
+	tr := &fakeTransport{body: body}
+	client := NewClient("AC123", "secret")
+	client.httpclient = &http.Client{Transport: tr}
+	store := optout.NewMemoryStore()
+	client.SetOptOutStore(store)
+	return client, tr, store
+}
+
+func TestSendMessageOptedOut(t *testing.T) {
+	client, tr, store := fakeClient(optedOutBody)
+	store.OptOut("+1 500 555 0001")
+
+	_, err := client.Request(SendMessage{From: "+15005550006", To: "+15005550001", Text: "hi"})
+	var optedOut *optout.OptedOutError
+	if !errors.As(err, &optedOut) || optedOut.Number != "+15005550001" {
+		t.Errorf("error %v", err)
+	}
+	if len(tr.requests) != 0 {
+		t.Errorf("%d requests made to twilio", len(tr.requests))
+	}
+
+	// other requests to the number are not blocked
+	if _, err := client.Request(Calls{To: "+15005550001"}); errors.As(err, &optedOut) {
+		t.Errorf("call listing blocked: %v", err)
+	}
+}
+
+func TestSendMessageRecordsOptOut(t *testing.T) {
+	client, tr, store := fakeClient(optedOutBody)
+	msg := SendMessage{From: "+15005550006", To: "+1 (500) 555-0002", Text: "hi"}
+
+	resp, err := client.Request(msg)
+	if err == nil || resp.Status.Twilio != optout.ErrorCode {
+		t.Fatalf("response %+v: %v", resp.Status, err)
+	}
+	if out, _ := store.OptedOut("+15005550002"); !out {
+		t.Fatal("21610 error not recorded")
+	}
+	if _, err := client.Request(msg); !errors.As(err, new(*optout.OptedOutError)) {
+		t.Errorf("second send: %v", err)
+	}
+	if len(tr.requests) != 1 {
+		t.Errorf("%d requests made to twilio, want 1", len(tr.requests))
+	}
+
+	// other errors are not recorded
+	client, _, store = fakeClient(strings.Replace(optedOutBody, "21610", "21211", 1))
+	client.Request(SendMessage{From: "+15005550006", To: "+15005550003", Text: "hi"})
+	if out, _ := store.OptedOut("+15005550003"); out {
+		t.Error("21211 error recorded as opt-out")
+	}
+}
@@ -94,6 +94,7 @@ type Participants struct {
 	subresource uri    `/Participants`
 	Sid         string // Conference Sid
 	Muted       string `Muted=`
+	Page        string `Page=`
 }
 
 // Resource about single conference participant
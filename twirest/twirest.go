@@ -19,6 +19,8 @@ import (
 	"net/url"
 	"reflect"
 	"strings"
+
+	"github.com/tmc/twilio/optout"
 )
 
 const ApiVer string = "2010-04-01"
@@ -32,6 +34,7 @@ const (
 type TwilioClient struct {
 	httpclient            *http.Client
 	accountSid, authToken string
+	optOuts               optout.Store
 }
 
 // Create a new client
@@ -50,7 +53,16 @@ func NewClient(accountSid, authToken string) *TwilioClient {
 		DisableCompression: true}
 	client := &http.Client{Transport: tr}
 
-	return &TwilioClient{client, accountSid, authToken}
+	return &TwilioClient{httpclient: client, accountSid: accountSid,
+		authToken: authToken}
+}
+
+// SetOptOutStore enables local opt-out enforcement: SendMessage requests to
+// numbers that opted out fail with an *optout.OptedOutError before any API
+// call is made, and recipients twilio rejects as opted out (error 21610) are
+// recorded in store.
+func (twiClient *TwilioClient) SetOptOutStore(store optout.Store) {
+	twiClient.optOuts = store
 }
 
 // Request makes a REST resource or action request from twilio servers and
@@ -61,6 +73,10 @@ func (twiClient *TwilioClient) Request(reqStruct interface{}) (
 
 	twiResp := TwilioResponse{}
 
+	if err := twiClient.checkOptOut(reqStruct); err != nil {
+		return twiResp, err
+	}
+
 	// setup a POST/GET/DELETE http request from request struct
 	httpReq, err := httpRequest(reqStruct, twiClient.accountSid)
 	if err != nil {
@@ -86,6 +102,7 @@ func (twiClient *TwilioClient) Request(reqStruct interface{}) (
 	xml.Unmarshal(body, &twiResp)
 
 	twiResp.Status.Twilio, err = exceptionToErr(twiResp)
+	twiClient.recordOptOut(reqStruct, twiResp.Status.Twilio)
 	return twiResp, err
 }
 
@@ -99,6 +116,10 @@ func (twiClient *TwilioClient) RequestWithContext(ctx context.Context, reqStruct
 
 	twiResp := TwilioResponse{}
 
+	if err := twiClient.checkOptOut(reqStruct); err != nil {
+		return twiResp, err
+	}
+
 	// setup a POST/GET/DELETE http request from request struct
 	httpReq, err := httpRequest(reqStruct, twiClient.accountSid)
 	if err != nil {
@@ -126,9 +147,39 @@ func (twiClient *TwilioClient) RequestWithContext(ctx context.Context, reqStruct
 	xml.Unmarshal(body, &twiResp)
 
 	twiResp.Status.Twilio, err = exceptionToErr(twiResp)
+	twiClient.recordOptOut(reqStruct, twiResp.Status.Twilio)
 	return twiResp, err
 }
 
+// checkOptOut returns an error if the request sends a message to a number
+// in the client's opt-out store
+func (twiClient *TwilioClient) checkOptOut(reqStruct interface{}) error {
+	msg, ok := reqStruct.(SendMessage)
+	if !ok || twiClient.optOuts == nil {
+		return nil
+	}
+	out, err := twiClient.optOuts.OptedOut(msg.To)
+	if err != nil {
+		return err
+	}
+	if out {
+		return &optout.OptedOutError{Number: msg.To}
+	}
+	return nil
+}
+
+// recordOptOut adds the recipient of a message twilio rejected as opted out
+// to the client's opt-out store
+func (twiClient *TwilioClient) recordOptOut(reqStruct interface{}, code int) {
+	msg, ok := reqStruct.(SendMessage)
+	if !ok || twiClient.optOuts == nil || code != optout.ErrorCode {
+		return
+	}
+	// the twilio exception is already returned to the caller, a failure to
+	// record it is not worth masking that error
+	twiClient.optOuts.OptOut(msg.To)
+}
+
 // exceptiontToErr converts a Twilio response exception (if any) to a go error
 func exceptionToErr(twir TwilioResponse) (code int, err error) {
 	if twir.Exception != nil {
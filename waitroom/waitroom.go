@@ -0,0 +1,215 @@
+// Package waitroom serves the waitUrl of an Enqueue: the TwiML twilio plays
+// to callers waiting in a queue. Callers hear hold music from a playlist,
+// are told their position and estimated wait from time to time, and may be
+// offered to press a key to leave the queue and be called back instead.
+package waitroom
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/tmc/twilio"
+	"github.com/tmc/twilio/twiml"
+)
+
+// DefaultAnnounceEvery is how often a handler without AnnounceEvery
+// announces the caller's position.
+const DefaultAnnounceEvery = time.Minute
+
+// Query parameters of the urls the handler points twilio back to. The wait
+// state is kept in them, so the handler needs no storage.
+const (
+	trackParam     = "track"     // index of the next track of the playlist
+	announcedParam = "announced" // queue time in seconds of the last announcement
+	eventParam     = "event"
+	eventCallback  = "callback" // Gather action with the caller's key
+)
+
+// Handler is an http.Handler serving the waitUrl of queues. Twilio requests
+// the wait url again each time its TwiML has been played, so each response
+// plays one track of the playlist, preceded by an announcement when one is
+// due.
+type Handler struct {
+	// Validator verifies the signature of each request.
+	twilio.Validator
+	// Playlists holds the hold music urls played in turn, by QueueSid. The
+	// playlist of the empty QueueSid is used for queues without their own.
+	// Callers of the same queue start at different tracks.
+	Playlists map[string][]string
+	// AnnounceEvery is the time between announcements of the caller's
+	// position, DefaultAnnounceEvery if zero. The first one is made as the
+	// caller enters the queue.
+	AnnounceEvery time.Duration
+	// Announce returns the announcement for a waiting caller, or "" to
+	// skip it. Announcement is used if nil.
+	Announce func(*twiml.QueueWaitRequest) string
+	// Voice and Language of the announcements and prompts.
+	Voice    string
+	Language string
+
+	// Callback is called when a caller asks to be called back by pressing
+	// CallbackDigit, before the caller leaves the queue. The caller goes on
+	// waiting if it returns an error. No callback is offered if nil.
+	Callback func(*twiml.GatherRequest) error
+	// CallbackDigit is the key offered for a callback, "1" if empty.
+	CallbackDigit string
+	// CallbackPrompt offers the callback while the music plays, e.g.
+	// "Press 1 to be called back."
+	CallbackPrompt string
+	// CallbackConfirm is said to the caller before leaving the queue.
+	CallbackConfirm string
+}
+
+// NewHandler creates a handler playing music to the callers of all queues.
+func NewHandler(music ...string) *Handler {
+	return &Handler{Playlists: map[string][]string{"": music}}
+}
+
+// Announcement is the default announcement, telling the caller's position and,
+// when the average wait of the queue is known, the estimated wait left.
+func Announcement(wr *twiml.QueueWaitRequest) string {
+	if wr.QueuePosition == 0 {
+		return ""
+	}
+	msg := "You are caller number " + strconv.Itoa(wr.QueuePosition) + " in line."
+	if wr.QueuePosition == 1 {
+		msg = "You are next in line."
+	}
+	if left := wr.AvgQueueTime - wr.QueueTime; left > 0 {
+		minutes := int((left + time.Minute - 1) / time.Minute)
+		if minutes == 1 {
+			msg += " The estimated wait time is about 1 minute."
+		} else {
+			msg += fmt.Sprintf(" The estimated wait time is about %d minutes.", minutes)
+		}
+	}
+	return msg
+}
+
+// ServeHTTP writes the TwiML of the next part of the wait, or handles the
+// key pressed by the caller.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.Verify(w, r) {
+		return
+	}
+	var resp *twiml.Response
+	var err error
+	if r.URL.Query().Get(eventParam) == eventCallback {
+		resp, err = h.callback(r)
+	} else {
+		resp, err = h.wait(r)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "text/xml")
+	resp.Send(w)
+}
+
+// wait renders an announcement if due and the next track, then redirects
+// back with the updated state
+func (h *Handler) wait(r *http.Request) (*twiml.Response, error) {
+	wr, err := twiml.ParseQueueWaitRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	q := r.URL.Query()
+	resp := twiml.NewResponse()
+
+	queueTime := int(wr.QueueTime / time.Second)
+	announced, err := strconv.Atoi(q.Get(announcedParam))
+	every := h.AnnounceEvery
+	if every == 0 {
+		every = DefaultAnnounceEvery
+	}
+	if err != nil || time.Duration(queueTime-announced)*time.Second >= every {
+		announce := h.Announce
+		if announce == nil {
+			announce = Announcement
+		}
+		if msg := announce(wr); msg != "" {
+			resp.Action(h.say(msg))
+		}
+		announced = queueTime
+	}
+
+	playlist, ok := h.Playlists[wr.QueueSid]
+	if !ok {
+		playlist = h.Playlists[""]
+	}
+	track, err := strconv.Atoi(q.Get(trackParam))
+	if err != nil && len(playlist) > 0 {
+		// spread callers over the playlist
+		f := fnv.New32a()
+		f.Write([]byte(wr.CallSid))
+		track = int(f.Sum32() % uint32(len(playlist)))
+	}
+	var music []interface{}
+	if len(playlist) > 0 {
+		track %= len(playlist)
+		music = append(music, twiml.Play{Url: playlist[track]})
+		track++
+	} else {
+		music = append(music, twiml.Pause{Length: 10})
+	}
+
+	if h.Callback != nil {
+		g := twiml.Gather{NumDigits: 1, Action: selfURL(r, url.Values{
+			eventParam:     {eventCallback},
+			trackParam:     {strconv.Itoa(track)},
+			announcedParam: {strconv.Itoa(announced)},
+		})}
+		if h.CallbackPrompt != "" {
+			music = append([]interface{}{h.say(h.CallbackPrompt)}, music...)
+		}
+		resp.Gather(append([]interface{}{g}, music...)...)
+	} else {
+		resp.Action(music...)
+	}
+	resp.Action(twiml.Redirect{Url: selfURL(r, url.Values{
+		trackParam:     {strconv.Itoa(track)},
+		announcedParam: {strconv.Itoa(announced)},
+	})})
+	return resp, nil
+}
+
+// callback handles the key pressed during the music: the caller leaves the
+// queue for a callback, or goes on waiting after another key
+func (h *Handler) callback(r *http.Request) (*twiml.Response, error) {
+	gr, err := twiml.ParseGatherRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	resp := twiml.NewResponse()
+	digit := h.CallbackDigit
+	if digit == "" {
+		digit = "1"
+	}
+	if h.Callback == nil || gr.Digits != digit || h.Callback(gr) != nil {
+		q := r.URL.Query()
+		resp.Action(twiml.Redirect{Url: selfURL(r, url.Values{
+			trackParam:     {q.Get(trackParam)},
+			announcedParam: {q.Get(announcedParam)},
+		})})
+		return resp, nil
+	}
+	if h.CallbackConfirm != "" {
+		resp.Action(h.say(h.CallbackConfirm))
+	}
+	resp.Action(twiml.Leave{})
+	return resp, nil
+}
+
+func (h *Handler) say(text string) twiml.Say {
+	return twiml.Say{Text: text, Voice: h.Voice, Language: h.Language}
+}
+
+// selfURL returns the path twilio requested with query q
+func selfURL(r *http.Request, q url.Values) string {
+	return twilio.RequestPath(r) + "?" + q.Encode()
+}
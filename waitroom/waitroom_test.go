@@ -0,0 +1,100 @@
+package waitroom_test
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/tmc/twilio/twiml"
+	"github.com/tmc/twilio/twiml/twimltest"
+	"github.com/tmc/twilio/waitroom"
+)
+
+var waiting = url.Values{
+	"QueueSid":         {"QU1"},
+	"QueuePosition":    {"3"},
+	"QueueTime":        {"30"},
+	"AvgQueueTime":     {"200"},
+	"CurrentQueueSize": {"5"},
+}
+
+func TestWait(t *testing.T) {
+	h := waitroom.NewHandler("a.mp3", "b.mp3")
+	res := twimltest.Call(t, h, "/wait", waiting).
+		Verbs("Say", "Play", "Redirect").
+		Say("Say", "You are caller number 3 in line. The estimated wait time is about 3 minutes.").
+		Valid()
+
+	// the redirect carries the next track and the time of the announcement
+	next := res.Find("Redirect").(twiml.Redirect).Url
+	u, _ := url.Parse(next)
+	if u.Query().Get("announced") != "30" {
+		t.Errorf("redirect %s", next)
+	}
+	first := res.Find("Play").(twiml.Play).Url
+	want := map[string]string{"a.mp3": "b.mp3", "b.mp3": "a.mp3"}[first]
+
+	waiting := url.Values{"QueueSid": {"QU1"}, "QueuePosition": {"2"}, "QueueTime": {"60"}}
+	twimltest.Call(t, h, next, waiting).
+		Verbs("Play", "Redirect").
+		Play("Play", want)
+
+	waiting.Set("QueueTime", "90")
+	twimltest.Call(t, h, next, waiting).
+		Verbs("Say", "Play", "Redirect").
+		Say("Say", "You are caller number 2 in line.")
+}
+
+func TestCallback(t *testing.T) {
+	var called []string
+	h := waitroom.NewHandler("a.mp3")
+	h.CallbackPrompt = "Press 1 to be called back."
+	h.CallbackConfirm = "We will call you back."
+	h.Callback = func(gr *twiml.GatherRequest) error {
+		called = append(called, gr.From)
+		return nil
+	}
+	res := twimltest.Call(t, h, "/wait", waiting).
+		Verbs("Say", "Gather", "Redirect").
+		Say("Gather/Say", "Press 1 to be called back.").
+		Play("Gather/Play", "a.mp3").
+		Valid()
+	action := res.Find("Gather").(twiml.Gather).Action
+	if !strings.Contains(action, "event=callback") {
+		t.Fatalf("gather action %s", action)
+	}
+
+	twimltest.Call(t, h, action, url.Values{"Digits": {"9"}}).Verbs("Redirect")
+	if len(called) != 0 {
+		t.Errorf("callback after wrong key")
+	}
+	twimltest.Call(t, h, action, url.Values{"Digits": {"1"}}).
+		Verbs("Say", "Leave").
+		Say("Say", "We will call you back.")
+	if len(called) != 1 || called[0] != "+15005550001" {
+		t.Errorf("callbacks %v", called)
+	}
+
+	// the caller keeps waiting when the callback can't be scheduled
+	h.Callback = func(*twiml.GatherRequest) error { return errors.New("full") }
+	twimltest.Call(t, h, action, url.Values{"Digits": {"1"}}).Verbs("Redirect")
+}
+
+func TestHandlerStripPrefix(t *testing.T) {
+	h := waitroom.NewHandler("a.mp3")
+	h.Callback = func(*twiml.GatherRequest) error { return nil }
+	mux := http.NewServeMux()
+	mux.Handle("/queue/", http.StripPrefix("/queue", h))
+
+	res := twimltest.Call(t, mux, "/queue/wait", waiting)
+	for _, u := range []string{
+		res.Find("Gather").(twiml.Gather).Action,
+		res.Find("Redirect").(twiml.Redirect).Url,
+	} {
+		if !strings.HasPrefix(u, "/queue/wait?") {
+			t.Errorf("url %s outside the mounted handler", u)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package twilio
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// RequestPath returns the path twilio requested, which differs from
+// r.URL.Path when the handler is mounted under http.StripPrefix. Urls in
+// TwiML pointing back to the handler are built from it.
+func RequestPath(r *http.Request) string {
+	if u, err := url.ParseRequestURI(r.RequestURI); err == nil && u.Path != "" {
+		return u.Path
+	}
+	return r.URL.Path
+}
+
+// Validator verifies the X-Twilio-Signature twilio adds to webhook requests.
+// The webhook handlers of this module embed it, and Wrap guards any other
+// handler.
+type Validator struct {
+	// AuthToken is the auth token of the account, which signs the requests.
+	// Verification is skipped if empty: the handler then serves forged
+	// requests too, which is only fit for tests.
+	AuthToken string
+	// BaseURL is the scheme and host twilio uses to reach the handler, e.g.
+	// "https://example.com", or "wss://example.com" for a websocket. It is
+	// derived from the request if empty.
+	BaseURL string
+}
+
+// Verify reports whether r may be served, answering it with 403 Forbidden
+// if its signature is not valid.
+func (v Validator) Verify(w http.ResponseWriter, r *http.Request) bool {
+	if v.AuthToken != "" && !ValidateRequest(v.AuthToken, v.BaseURL, r) {
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// Wrap returns a handler serving the requests passing Verify with h.
+func (v Validator) Wrap(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if v.Verify(w, r) {
+			h.ServeHTTP(w, r)
+		}
+	})
+}
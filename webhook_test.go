@@ -0,0 +1,43 @@
+package twilio_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/tmc/twilio"
+)
+
+func TestRequestPath(t *testing.T) {
+	var path string
+	h := http.StripPrefix("/voice", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = twilio.RequestPath(r)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/voice/ivr?event=input", nil))
+	if path != "/voice/ivr" {
+		t.Errorf("path %s", path)
+	}
+}
+
+func TestValidatorWrap(t *testing.T) {
+	served := 0
+	h := twilio.Validator{AuthToken: "secret", BaseURL: "https://example.com"}.Wrap(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { served++ }))
+
+	form := url.Values{"CallSid": {"CA1234567890ABCDE"}}
+	for _, sig := range []string{twilio.Signature("secret", "https://example.com/voice", form), "forged", ""} {
+		r := httptest.NewRequest("POST", "/voice", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set(twilio.SignatureHeader, sig)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if valid := sig != "forged" && sig != ""; valid != (w.Code == http.StatusOK) {
+			t.Errorf("signature %q: status %d", sig, w.Code)
+		}
+	}
+	if served != 1 {
+		t.Errorf("served %d requests", served)
+	}
+}